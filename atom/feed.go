@@ -49,6 +49,7 @@ type Entry struct {
 	PublishedParsed *time.Time     `json:"publishedParsed,omitempty"`
 	Source          *Source        `json:"source,omitempty"`
 	Content         *Content       `json:"content,omitempty"`
+	Language        string         `json:"language,omitempty"`
 	Extensions      ext.Extensions `json:"extensions,omitempty"`
 }
 
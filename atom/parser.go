@@ -12,11 +12,28 @@ import (
 )
 
 // Parser is an Atom Parser
-type Parser struct{}
+type Parser struct {
+	// MaxItems, when positive, stops parsing once that many
+	// entries were read.  The rest of the input is not read,
+	// so feed elements after the last entry are lost.
+	MaxItems int
+
+	// ResolveRelativeLinks resolves relative link hrefs
+	// against the xml:base in scope.
+	ResolveRelativeLinks bool
+
+	// CharsetReader converts the input of a non utf-8 feed
+	// to utf-8.  When nil, shared.NewReaderLabel is used.
+	CharsetReader func(charset string, input io.Reader) (io.Reader, error)
+}
 
 // Parse parses an xml feed into an atom.Feed
 func (ap *Parser) Parse(feed io.Reader) (*Feed, error) {
-	p := xpp.NewXMLPullParser(feed, false, shared.NewReaderLabel)
+	charsetReader := ap.CharsetReader
+	if charsetReader == nil {
+		charsetReader = shared.NewReaderLabel
+	}
+	p := xpp.NewXMLPullParser(feed, false, charsetReader)
 
 	_, err := shared.FindRoot(p)
 	if err != nil {
@@ -148,6 +165,9 @@ func (ap *Parser) parseRoot(p *xpp.XMLPullParser) (*Feed, error) {
 					return nil, err
 				}
 				atom.Entries = append(atom.Entries, result)
+				if ap.full(len(atom.Entries)) {
+					break
+				}
 			} else {
 				err := p.Skip()
 				if err != nil {
@@ -177,6 +197,10 @@ func (ap *Parser) parseRoot(p *xpp.XMLPullParser) (*Feed, error) {
 		atom.Extensions = extensions
 	}
 
+	if ap.full(len(atom.Entries)) {
+		return atom, nil
+	}
+
 	if err := p.Expect(xpp.EndTag, "feed"); err != nil {
 		return nil, err
 	}
@@ -184,11 +208,17 @@ func (ap *Parser) parseRoot(p *xpp.XMLPullParser) (*Feed, error) {
 	return atom, nil
 }
 
+// full reports whether n entries reach MaxItems
+func (ap *Parser) full(n int) bool {
+	return ap.MaxItems > 0 && n >= ap.MaxItems
+}
+
 func (ap *Parser) parseEntry(p *xpp.XMLPullParser) (*Entry, error) {
 	if err := p.Expect(xpp.StartTag, "entry"); err != nil {
 		return nil, err
 	}
 	entry := &Entry{}
+	entry.Language = ap.parseLanguage(p)
 
 	contributors := []*Person{}
 	authors := []*Person{}
@@ -566,6 +596,9 @@ func (ap *Parser) parseLink(p *xpp.XMLPullParser) (*Link, error) {
 
 	l := &Link{}
 	l.Href = p.Attribute("href")
+	if ap.ResolveRelativeLinks {
+		l.Href = shared.ResolveURL(p.BaseStack.Top(), l.Href)
+	}
 	l.Hreflang = p.Attribute("hreflang")
 	l.Type = p.Attribute("type")
 	l.Length = p.Attribute("length")
@@ -664,25 +697,43 @@ func (ap *Parser) parseAtomText(p *xpp.XMLPullParser) (string, error) {
 	lowerType := strings.ToLower(text.Type)
 	lowerMode := strings.ToLower(text.Mode)
 
-	if lowerType == "text" ||
+	if lowerMode == "base64" {
+		result = decodeBase64(result)
+	} else if lowerType == "text" ||
 		strings.HasPrefix(lowerType, "text/") ||
+		lowerMode == "escaped" ||
 		(lowerType == "" && lowerMode == "") {
 		result, err = shared.DecodeEntities(result)
 	} else if strings.Contains(lowerType, "xhtml") {
 		result = ap.stripWrappingDiv(result)
 	} else if lowerType == "html" {
-		result = ap.stripWrappingDiv(result)
+		// The markup is escaped, so any wrapping div only
+		// shows up once the entities are decoded.
 		result, err = shared.DecodeEntities(result)
-	} else {
-		decodedStr, err := base64.StdEncoding.DecodeString(result)
 		if err == nil {
-			result = string(decodedStr)
+			result = ap.stripWrappingDiv(result)
 		}
+	} else if strings.HasSuffix(lowerType, "/xml") ||
+		strings.HasSuffix(lowerType, "+xml") {
+		// Other xml media types are inlined as is
+	} else {
+		result = decodeBase64(result)
 	}
 
 	return result, err
 }
 
+// decodeBase64 decodes base64 content, which is often
+// wrapped over several lines.  Content that isn't valid
+// base64 is returned unchanged.
+func decodeBase64(content string) string {
+	decoded, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(content), ""))
+	if err != nil {
+		return content
+	}
+	return string(decoded)
+}
+
 func (ap *Parser) parseLanguage(p *xpp.XMLPullParser) string {
 	return p.Attribute("lang")
 }
@@ -1,7 +1,13 @@
 package gofeed
 
 import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"fmt"
 	"io"
+	"net/http"
+	"os"
 	"strings"
 
 	"github.com/mmcdole/goxpp"
@@ -22,13 +28,20 @@ const (
 	FeedTypeRSS
 	//FeedTypeSitemap represents a sitemap feed
 	FeedTypeSitemap
+	// FeedTypeOPML represents an OPML subscription list,
+	// parsed with the opml package rather than Parser
+	FeedTypeOPML
 )
 
 // DetectFeedType attempts to determine the type of feed
 // by looking for specific xml elements unique to the
 // various feed types.
 func DetectFeedType(feed io.Reader) FeedType {
-	p := xpp.NewXMLPullParser(feed, false, shared.NewReaderLabel)
+	return detectFeedType(feed, shared.NewReaderLabel)
+}
+
+func detectFeedType(feed io.Reader, charsetReader func(string, io.Reader) (io.Reader, error)) FeedType {
+	p := xpp.NewXMLPullParser(feed, false, charsetReader)
 
 	_, err := shared.FindRoot(p)
 	if err != nil {
@@ -43,9 +56,136 @@ func DetectFeedType(feed io.Reader) FeedType {
 		return FeedTypeRSS
 	case "feed":
 		return FeedTypeAtom
-	case "urlset":
+	case "urlset", "sitemapindex":
 		return FeedTypeSitemap
+	case "opml":
+		return FeedTypeOPML
 	default:
 		return FeedTypeUnknown
 	}
 }
+
+// detectPeekSize is the most of a body DetectFeedTypeURL
+// reads while looking for the root element.
+const detectPeekSize = 64 * 1024
+
+// DetectFeedTypeURL determines the type of the feed at the given
+// url without parsing it.  Only the start of the body is requested
+// and read, the rest of the response is discarded.  Gzip compressed
+// bodies are detected by their magic bytes and when the root element
+// can't be found the response Content-Type is used as a hint.
+// The request is made like ParseURL's with a default Parser, see
+// Parser.DetectFeedTypeURL.
+func DetectFeedTypeURL(ctx context.Context, feedURL string) (FeedType, error) {
+	return NewParser().DetectFeedTypeURL(ctx, feedURL)
+}
+
+// DetectFeedTypeURL is like the package level DetectFeedTypeURL,
+// but fetches the url the way ParseURL does, through the Parser's
+// Fetcher or http client and within its MaxConcurrent and
+// HostRequestBudget limits.  A Fetcher can't be asked for only the
+// start of the body, so the rest of its response is discarded.
+func (f *Parser) DetectFeedTypeURL(ctx context.Context, feedURL string) (FeedType, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if err := f.acquire(ctx); err != nil {
+		return FeedTypeUnknown, err
+	}
+	defer f.release()
+
+	var body io.ReadCloser
+	var header http.Header
+	if f.Fetcher != nil {
+		var meta *ResponseMeta
+		var err error
+		body, meta, err = f.open(ctx, feedURL)
+		if err != nil {
+			return FeedTypeUnknown, err
+		}
+		if meta != nil {
+			header = meta.Header
+		}
+	} else {
+		resp, err := f.fetchWithClient(ctx, f.httpClient(), feedURL, func(req *http.Request) {
+			req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", detectPeekSize-1))
+		})
+		if err != nil {
+			return FeedTypeUnknown, err
+		}
+		body, header = resp.Body, resp.Header
+	}
+	defer body.Close()
+
+	r, err := gunzipReader(io.LimitReader(body, detectPeekSize))
+	if err != nil {
+		return FeedTypeUnknown, err
+	}
+
+	feedType := DetectFeedType(r)
+	if feedType == FeedTypeUnknown {
+		feedType = feedTypeForContentType(header.Get("Content-Type"))
+	}
+	return feedType, nil
+}
+
+// gunzipReader decompresses r if it starts with the gzip
+// magic bytes and returns it untouched otherwise.
+func gunzipReader(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err != nil || magic[0] != 0x1f || magic[1] != 0x8b {
+		return br, nil
+	}
+	return gzip.NewReader(br)
+}
+
+func feedTypeForContentType(contentType string) FeedType {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	switch mediaType {
+	case "application/atom+xml":
+		return FeedTypeAtom
+	case "application/rss+xml", "application/rdf+xml":
+		return FeedTypeRSS
+	case "text/x-opml", "application/opml+xml":
+		return FeedTypeOPML
+	}
+	return FeedTypeUnknown
+}
+
+// SourceType represents the kind of feed source
+// string that was given to Parser.ParseAny.
+type SourceType int
+
+const (
+	// SourceTypeString represents raw feed content
+	SourceTypeString SourceType = iota
+	// SourceTypeURL represents an http or https url
+	SourceTypeURL
+	// SourceTypeFile represents a path to a local file
+	SourceTypeFile
+)
+
+// DetectSourceType determines what kind of source a string
+// is.  Strings starting with http:// or https:// are urls,
+// strings naming an existing regular file are file paths and
+// everything else is treated as raw feed content.
+func DetectSourceType(source string) SourceType {
+	s := strings.TrimSpace(source)
+	lower := strings.ToLower(s)
+	if strings.HasPrefix(lower, "http://") ||
+		strings.HasPrefix(lower, "https://") {
+		return SourceTypeURL
+	}
+
+	// Feed content can never be a file path, so
+	// don't bother hitting the filesystem for it.
+	if strings.HasPrefix(s, "<") {
+		return SourceTypeString
+	}
+
+	if info, err := os.Stat(s); err == nil && info.Mode().IsRegular() {
+		return SourceTypeFile
+	}
+	return SourceTypeString
+}
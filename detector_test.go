@@ -2,8 +2,15 @@ package gofeed_test
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path"
 	"strings"
 	"testing"
 
@@ -22,6 +29,10 @@ func TestDetectFeedType(t *testing.T) {
 		{"rdf_feed.xml", gofeed.FeedTypeRSS},
 		{"unknown_feed.xml", gofeed.FeedTypeUnknown},
 		{"empty_feed.xml", gofeed.FeedTypeUnknown},
+		{"../sitemap/sitemao01_news.xml", gofeed.FeedTypeSitemap},
+		{"../sitemap/sitemap_index.xml", gofeed.FeedTypeSitemap},
+		{"../sitemap/sitemap_prefixed_ns.xml", gofeed.FeedTypeSitemap},
+		{"../opml/opml_nested.xml", gofeed.FeedTypeOPML},
 	}
 
 	for _, test := range feedTypeTests {
@@ -42,6 +53,24 @@ func TestDetectFeedType(t *testing.T) {
 	}
 }
 
+func TestDetectSourceType(t *testing.T) {
+	var sourceTests = []struct {
+		source   string
+		expected gofeed.SourceType
+	}{
+		{"http://example.com/feed.xml", gofeed.SourceTypeURL},
+		{" HTTPS://example.com/feed.xml", gofeed.SourceTypeURL},
+		{"testdata/parser/universal/rss_feed.xml", gofeed.SourceTypeFile},
+		{"testdata/parser/universal", gofeed.SourceTypeString},
+		{"testdata/parser/universal/missing.xml", gofeed.SourceTypeString},
+		{"<rss version=\"2.0\"></rss>", gofeed.SourceTypeString},
+	}
+
+	for _, test := range sourceTests {
+		assert.Equal(t, test.expected, gofeed.DetectSourceType(test.source), "source %q", test.source)
+	}
+}
+
 // Examples
 
 func ExampleDetectFeedType() {
@@ -55,3 +84,145 @@ func ExampleDetectFeedType() {
 		fmt.Println("Wow! This is an RSS feed!")
 	}
 }
+
+func TestDetectFeedTypeURL(t *testing.T) {
+	rssFeed, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed.xml")
+	atomFeed, _ := ioutil.ReadFile("testdata/parser/universal/atom10_feed.xml")
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	gw.Write(atomFeed)
+	gw.Close()
+
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ranges = append(ranges, r.Header.Get("Range"))
+		switch r.URL.Path {
+		case "/rss":
+			w.Write(rssFeed)
+		case "/gzip":
+			w.Write(gzipped.Bytes())
+		case "/hint":
+			w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+			w.Write([]byte("not xml"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	var urlTests = []struct {
+		path     string
+		expected gofeed.FeedType
+		hasError bool
+	}{
+		{"/rss", gofeed.FeedTypeRSS, false},
+		{"/gzip", gofeed.FeedTypeAtom, false},
+		{"/hint", gofeed.FeedTypeAtom, false},
+		{"/missing", gofeed.FeedTypeUnknown, true},
+	}
+
+	for _, test := range urlTests {
+		actual, err := gofeed.DetectFeedTypeURL(context.Background(), server.URL+test.path)
+		if test.hasError {
+			assert.NotNil(t, err)
+		} else {
+			assert.Nil(t, err)
+		}
+		assert.Equal(t, test.expected, actual, "Path %s did not match expected type %d", test.path, test.expected)
+	}
+	assert.Equal(t, "bytes=0-65535", ranges[0])
+
+	// A cancelled context fails the request
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := gofeed.DetectFeedTypeURL(ctx, server.URL+"/rss")
+	assert.NotNil(t, err)
+}
+
+func TestParser_DetectFeedTypeURL(t *testing.T) {
+	rssFeed, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed.xml")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(rssFeed)
+	}))
+	defer server.Close()
+
+	// The request goes through the parser's transport
+	fp := gofeed.NewParser()
+	fp.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, errors.New("address not allowed")
+	}
+	_, err := fp.DetectFeedTypeURL(context.Background(), server.URL)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "address not allowed")
+	}
+
+	// and counts against the host budget
+	fp = gofeed.NewParser()
+	fp.HostRequestBudget = 1
+	feedType, err := fp.DetectFeedTypeURL(context.Background(), server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, gofeed.FeedTypeRSS, feedType)
+	_, err = fp.DetectFeedTypeURL(context.Background(), server.URL)
+	assert.IsType(t, gofeed.HostBudgetError{}, err)
+
+	// A Fetcher replaces the http client
+	fp = gofeed.NewParser()
+	fp.Fetcher = mapFetcher{"http://example.com/feed": string(rssFeed)}
+	feedType, err = fp.DetectFeedTypeURL(context.Background(), "http://example.com/feed")
+	assert.Nil(t, err)
+	assert.Equal(t, gofeed.FeedTypeRSS, feedType)
+}
+
+func TestDetectFeedTypeURL_MismatchedExtension(t *testing.T) {
+	bodies := map[string]gofeed.FeedType{
+		"universal/rss_feed.xml":     gofeed.FeedTypeRSS,
+		"universal/rdf_feed.xml":     gofeed.FeedTypeRSS,
+		"universal/atom10_feed.xml":  gofeed.FeedTypeAtom,
+		"sitemap/sitemao01_news.xml": gofeed.FeedTypeSitemap,
+		"sitemap/sitemap_index.xml":  gofeed.FeedTypeSitemap,
+		"opml/opml_nested.xml":       gofeed.FeedTypeOPML,
+	}
+	extensions := map[string]string{
+		".rss":  "application/rss+xml",
+		".atom": "application/atom+xml",
+		".xml":  "text/xml",
+		".opml": "text/x-opml",
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// /<fixture><extension>, served with the content type of
+		// the extension rather than of the body
+		ext := path.Ext(r.URL.Path)
+		f, err := ioutil.ReadFile("testdata/parser/" + strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"), ext))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", extensions[ext])
+		w.Write(f)
+	}))
+	defer server.Close()
+
+	for body, expected := range bodies {
+		for ext := range extensions {
+			feedURL := server.URL + "/" + body + ext
+
+			actual, err := gofeed.DetectFeedTypeURL(context.Background(), feedURL)
+			assert.Nil(t, err)
+			assert.Equal(t, expected, actual, "%s served as %s", body, ext)
+
+			// The parser routes on the body as well
+			feedType := map[gofeed.FeedType]string{
+				gofeed.FeedTypeRSS:  "rss",
+				gofeed.FeedTypeAtom: "atom",
+			}[expected]
+			if feedType == "" {
+				continue
+			}
+			feed, err := gofeed.NewParser().ParseURL(feedURL)
+			if assert.Nil(t, err, "%s served as %s", body, ext) {
+				assert.Equal(t, feedType, feed.FeedType, "%s served as %s", body, ext)
+			}
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package ext
 
+import "reflect"
+
 // Extensions is the generic extension map for Feeds and Items.
 // The first map is for the element namespace prefix (e.g., itunes).
 // The second map is for the element name (e.g., author).
@@ -14,6 +16,31 @@ type Extension struct {
 	Children map[string][]Extension `json:"children"`
 }
 
+// Dedupe removes repeated extension elements that are identical
+// (same value, attributes and children) to an earlier element in
+// the same namespace prefix and element name bucket.  The order of
+// the remaining elements is preserved.
+func (e Extensions) Dedupe() {
+	for _, names := range e {
+		for name, exts := range names {
+			unique := []Extension{}
+			for _, candidate := range exts {
+				seen := false
+				for _, u := range unique {
+					if reflect.DeepEqual(u, candidate) {
+						seen = true
+						break
+					}
+				}
+				if !seen {
+					unique = append(unique, candidate)
+				}
+			}
+			names[name] = unique
+		}
+	}
+}
+
 func parseTextExtension(name string, extensions map[string][]Extension) (value string) {
 	if extensions == nil {
 		return
@@ -0,0 +1,163 @@
+package ext
+
+// MediaExtension is a set of Media RSS extension
+// fields for RSS items and Atom entries.
+type MediaExtension struct {
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Keywords    string            `json:"keywords,omitempty"`
+	Contents    []*MediaContent   `json:"contents,omitempty"`
+	Groups      []*MediaGroup     `json:"groups,omitempty"`
+	Thumbnails  []*MediaThumbnail `json:"thumbnails,omitempty"`
+	Ratings     []*MediaRating    `json:"ratings,omitempty"`
+}
+
+// MediaGroup is a media:group element, holding alternate
+// versions of the same media object.
+type MediaGroup struct {
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Contents    []*MediaContent   `json:"contents,omitempty"`
+	Thumbnails  []*MediaThumbnail `json:"thumbnails,omitempty"`
+	Ratings     []*MediaRating    `json:"ratings,omitempty"`
+}
+
+// MediaContent is a media:content element describing
+// a single media object.
+type MediaContent struct {
+	URL         string            `json:"url,omitempty"`
+	Type        string            `json:"type,omitempty"`
+	Medium      string            `json:"medium,omitempty"`
+	FileSize    string            `json:"fileSize,omitempty"`
+	Duration    string            `json:"duration,omitempty"`
+	Width       string            `json:"width,omitempty"`
+	Height      string            `json:"height,omitempty"`
+	IsDefault   string            `json:"isDefault,omitempty"`
+	Title       string            `json:"title,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Thumbnails  []*MediaThumbnail `json:"thumbnails,omitempty"`
+	Ratings     []*MediaRating    `json:"ratings,omitempty"`
+}
+
+// MediaThumbnail is a media:thumbnail image.
+type MediaThumbnail struct {
+	URL    string `json:"url,omitempty"`
+	Width  string `json:"width,omitempty"`
+	Height string `json:"height,omitempty"`
+	Time   string `json:"time,omitempty"`
+}
+
+// MediaRating is a media:rating element.
+type MediaRating struct {
+	Scheme string `json:"scheme,omitempty"`
+	Value  string `json:"value,omitempty"`
+}
+
+// NewMediaExtension creates a MediaExtension given an
+// extension map for the "media" key.
+func NewMediaExtension(extensions map[string][]Extension) *MediaExtension {
+	media := &MediaExtension{}
+	media.Title = parseTextExtension("title", extensions)
+	media.Description = parseTextExtension("description", extensions)
+	media.Keywords = parseTextExtension("keywords", extensions)
+	media.Contents = parseMediaContents(extensions)
+	media.Groups = parseMediaGroups(extensions)
+	media.Thumbnails = parseMediaThumbnails(extensions)
+	media.Ratings = parseMediaRatings(extensions)
+	return media
+}
+
+func parseMediaGroups(extensions map[string][]Extension) (groups []*MediaGroup) {
+	if extensions == nil {
+		return
+	}
+
+	matches, ok := extensions["group"]
+	if !ok || len(matches) == 0 {
+		return
+	}
+
+	groups = []*MediaGroup{}
+	for _, m := range matches {
+		g := &MediaGroup{}
+		g.Title = parseTextExtension("title", m.Children)
+		g.Description = parseTextExtension("description", m.Children)
+		g.Contents = parseMediaContents(m.Children)
+		g.Thumbnails = parseMediaThumbnails(m.Children)
+		g.Ratings = parseMediaRatings(m.Children)
+		groups = append(groups, g)
+	}
+	return
+}
+
+func parseMediaContents(extensions map[string][]Extension) (contents []*MediaContent) {
+	if extensions == nil {
+		return
+	}
+
+	matches, ok := extensions["content"]
+	if !ok || len(matches) == 0 {
+		return
+	}
+
+	contents = []*MediaContent{}
+	for _, m := range matches {
+		c := &MediaContent{}
+		c.URL = m.Attrs["url"]
+		c.Type = m.Attrs["type"]
+		c.Medium = m.Attrs["medium"]
+		c.FileSize = m.Attrs["fileSize"]
+		c.Duration = m.Attrs["duration"]
+		c.Width = m.Attrs["width"]
+		c.Height = m.Attrs["height"]
+		c.IsDefault = m.Attrs["isDefault"]
+		c.Title = parseTextExtension("title", m.Children)
+		c.Description = parseTextExtension("description", m.Children)
+		c.Thumbnails = parseMediaThumbnails(m.Children)
+		c.Ratings = parseMediaRatings(m.Children)
+		contents = append(contents, c)
+	}
+	return
+}
+
+func parseMediaThumbnails(extensions map[string][]Extension) (thumbnails []*MediaThumbnail) {
+	if extensions == nil {
+		return
+	}
+
+	matches, ok := extensions["thumbnail"]
+	if !ok || len(matches) == 0 {
+		return
+	}
+
+	thumbnails = []*MediaThumbnail{}
+	for _, m := range matches {
+		t := &MediaThumbnail{}
+		t.URL = m.Attrs["url"]
+		t.Width = m.Attrs["width"]
+		t.Height = m.Attrs["height"]
+		t.Time = m.Attrs["time"]
+		thumbnails = append(thumbnails, t)
+	}
+	return
+}
+
+func parseMediaRatings(extensions map[string][]Extension) (ratings []*MediaRating) {
+	if extensions == nil {
+		return
+	}
+
+	matches, ok := extensions["rating"]
+	if !ok || len(matches) == 0 {
+		return
+	}
+
+	ratings = []*MediaRating{}
+	for _, m := range matches {
+		r := &MediaRating{}
+		r.Scheme = m.Attrs["scheme"]
+		r.Value = m.Value
+		ratings = append(ratings, r)
+	}
+	return
+}
@@ -11,25 +11,32 @@ import (
 // and rss.Feed gets translated to. It represents
 // a web feed.
 type Feed struct {
-	Title           string            `json:"title,omitempty"`
-	Description     string            `json:"description,omitempty"`
-	Link            string            `json:"link,omitempty"`
-	FeedLink        string            `json:"feedLink,omitempty"`
-	Updated         string            `json:"updated,omitempty"`
-	UpdatedParsed   *time.Time        `json:"updatedParsed,omitempty"`
-	Published       string            `json:"published,omitempty"`
-	PublishedParsed *time.Time        `json:"publishedParsed,omitempty"`
-	Author          *Person           `json:"author,omitempty"`
-	Language        string            `json:"language,omitempty"`
-	Image           *Image            `json:"image,omitempty"`
-	Copyright       string            `json:"copyright,omitempty"`
-	Generator       string            `json:"generator,omitempty"`
-	Categories      []string          `json:"categories,omitempty"`
-	Extensions      ext.Extensions    `json:"extensions,omitempty"`
-	Custom          map[string]string `json:"custom,omitempty"`
-	Items           []*Item           `json:"items"`
-	FeedType        string            `json:"feedType"`
-	FeedVersion     string            `json:"feedVersion"`
+	Title           string                   `json:"title,omitempty"`
+	Description     string                   `json:"description,omitempty"`
+	Link            string                   `json:"link,omitempty"`
+	FeedLink        string                   `json:"feedLink,omitempty"`
+	HubLink         string                   `json:"hubLink,omitempty"`
+	Cloud           *Cloud                   `json:"cloud,omitempty"`
+	Updated         string                   `json:"updated,omitempty"`
+	UpdatedParsed   *time.Time               `json:"updatedParsed,omitempty"`
+	Published       string                   `json:"published,omitempty"`
+	PublishedParsed *time.Time               `json:"publishedParsed,omitempty"`
+	Author          *Person                  `json:"author,omitempty"`
+	Language        string                   `json:"language,omitempty"`
+	Image           *Image                   `json:"image,omitempty"`
+	Copyright       string                   `json:"copyright,omitempty"`
+	Generator       string                   `json:"generator,omitempty"`
+	Docs            string                   `json:"docs,omitempty"`
+	Categories      []string                 `json:"categories,omitempty"`
+	TTL             int                      `json:"ttl,omitempty"`
+	SkipHours       []int                    `json:"skipHours,omitempty"`
+	SkipDays        []string                 `json:"skipDays,omitempty"`
+	ITunesExt       *ext.ITunesFeedExtension `json:"itunesExt,omitempty"`
+	Extensions      ext.Extensions           `json:"extensions,omitempty"`
+	Custom          map[string]string        `json:"custom,omitempty"`
+	Items           []*Item                  `json:"items"`
+	FeedType        string                   `json:"feedType"`
+	FeedVersion     string                   `json:"feedVersion"`
 }
 
 func (f Feed) String() string {
@@ -37,25 +44,74 @@ func (f Feed) String() string {
 	return string(json)
 }
 
+// IsStale reports whether the most recent date of the feed
+// is older than maxAge.  The feed updated and published dates
+// and those of every item are considered, so a feed with an
+// old lastBuildDate but new items is still fresh.  A feed
+// without any parsed date is stale.
+func (f Feed) IsStale(maxAge time.Duration) bool {
+	return f.IsStaleAt(time.Now(), maxAge)
+}
+
+// IsStaleAt is IsStale with the age measured at now instead
+// of the current time, e.g. the Parser's Now clock.
+func (f Feed) IsStaleAt(now time.Time, maxAge time.Duration) bool {
+	latest := f.latestDate()
+	if latest == nil {
+		return true
+	}
+	return now.Sub(*latest) > maxAge
+}
+
+func (f Feed) latestDate() (latest *time.Time) {
+	dates := []*time.Time{f.UpdatedParsed, f.PublishedParsed}
+	for _, item := range f.Items {
+		dates = append(dates, item.UpdatedParsed, item.PublishedParsed)
+	}
+
+	for _, date := range dates {
+		if date != nil && (latest == nil || date.After(*latest)) {
+			latest = date
+		}
+	}
+	return
+}
+
 // Item is the universal Item type that atom.Entry
 // and rss.Item gets translated to.  It represents
 // a single entry in a given feed.
 type Item struct {
-	Title           string            `json:"title,omitempty"`
-	Description     string            `json:"description,omitempty"`
-	Content         string            `json:"content,omitempty"`
-	Link            string            `json:"link,omitempty"`
-	Updated         string            `json:"updated,omitempty"`
-	UpdatedParsed   *time.Time        `json:"updatedParsed,omitempty"`
-	Published       string            `json:"published,omitempty"`
-	PublishedParsed *time.Time        `json:"publishedParsed,omitempty"`
-	Author          *Person           `json:"author,omitempty"`
-	GUID            string            `json:"guid,omitempty"`
-	Image           *Image            `json:"image,omitempty"`
-	Categories      []string          `json:"categories,omitempty"`
-	Enclosures      []*Enclosure      `json:"enclosures,omitempty"`
-	Extensions      ext.Extensions    `json:"extensions,omitempty"`
-	Custom          map[string]string `json:"custom,omitempty"`
+	Title           string                   `json:"title,omitempty"`
+	Description     string                   `json:"description,omitempty"`
+	Content         string                   `json:"content,omitempty"`
+	Link            string                   `json:"link,omitempty"`
+	Links           []*Link                  `json:"links,omitempty"`
+	Updated         string                   `json:"updated,omitempty"`
+	UpdatedParsed   *time.Time               `json:"updatedParsed,omitempty"`
+	Published       string                   `json:"published,omitempty"`
+	PublishedParsed *time.Time               `json:"publishedParsed,omitempty"`
+	Author          *Person                  `json:"author,omitempty"`
+	GUID            string                   `json:"guid,omitempty"`
+	Image           *Image                   `json:"image,omitempty"`
+	Categories      []string                 `json:"categories,omitempty"`
+	Enclosures      []*Enclosure             `json:"enclosures,omitempty"`
+	Language        string                   `json:"language,omitempty"`
+	Copyright       string                   `json:"copyright,omitempty"`
+	Source          *Source                  `json:"source,omitempty"`
+	Media           *ext.MediaExtension      `json:"media,omitempty"`
+	Sitemap         *SitemapExtra            `json:"sitemap,omitempty"`
+	ITunesExt       *ext.ITunesItemExtension `json:"itunesExt,omitempty"`
+	Extensions      ext.Extensions           `json:"extensions,omitempty"`
+	Custom          map[string]string        `json:"custom,omitempty"`
+}
+
+// SitemapExtra holds the sitemap specific data of an Item
+// that was translated from a sitemap url entry.
+type SitemapExtra struct {
+	LastMod       string     `json:"lastmod,omitempty"`
+	LastModParsed *time.Time `json:"lastmodParsed,omitempty"`
+	ChangeFreq    string     `json:"changefreq,omitempty"`
+	Priority      string     `json:"priority,omitempty"`
 }
 
 // Person is an individual specified in a feed
@@ -72,9 +128,38 @@ type Image struct {
 	Title string `json:"title,omitempty"`
 }
 
+// Source is the feed an Item was originally
+// published in, for re-syndicated items.
+type Source struct {
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url,omitempty"`
+}
+
+// Cloud is an rssCloud endpoint readers can register
+// with to be notified of updates to the feed.
+type Cloud struct {
+	Domain            string `json:"domain,omitempty"`
+	Port              string `json:"port,omitempty"`
+	Path              string `json:"path,omitempty"`
+	RegisterProcedure string `json:"registerProcedure,omitempty"`
+	Protocol          string `json:"protocol,omitempty"`
+}
+
 // Enclosure is a file associated with a given Item.
 type Enclosure struct {
 	URL    string `json:"url,omitempty"`
 	Length string `json:"length,omitempty"`
 	Type   string `json:"type,omitempty"`
 }
+
+// Link is one of the links of an atom entry, with the
+// relation it has to the entry, e.g. "alternate", "related"
+// or "enclosure".
+type Link struct {
+	Href     string `json:"href,omitempty"`
+	Rel      string `json:"rel,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Hreflang string `json:"hreflang,omitempty"`
+	Length   string `json:"length,omitempty"`
+}
@@ -0,0 +1,139 @@
+package gofeed_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shuyaoyimei/gofeed"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeed_IsStale(t *testing.T) {
+	now := time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+	hourAgo := now.Add(-time.Hour)
+	weekAgo := now.Add(-7 * 24 * time.Hour)
+	inFuture := now.Add(time.Hour)
+
+	var staleTests = []struct {
+		name     string
+		feed     gofeed.Feed
+		expected bool
+	}{
+		{"no dates", gofeed.Feed{}, true},
+		{"no item dates", gofeed.Feed{Items: []*gofeed.Item{{Title: "Item"}}}, true},
+		{"recent feed updated", gofeed.Feed{UpdatedParsed: &hourAgo}, false},
+		{"old feed updated", gofeed.Feed{UpdatedParsed: &weekAgo}, true},
+		{"old feed published", gofeed.Feed{PublishedParsed: &weekAgo}, true},
+		{"future feed updated", gofeed.Feed{UpdatedParsed: &inFuture}, false},
+		{"recent item published", gofeed.Feed{
+			UpdatedParsed: &weekAgo,
+			Items:         []*gofeed.Item{{PublishedParsed: &weekAgo}, {PublishedParsed: &hourAgo}},
+		}, false},
+		{"recent item updated", gofeed.Feed{
+			Items: []*gofeed.Item{{PublishedParsed: &weekAgo, UpdatedParsed: &hourAgo}},
+		}, false},
+		{"old items", gofeed.Feed{
+			Items: []*gofeed.Item{{PublishedParsed: &weekAgo}, {}},
+		}, true},
+	}
+
+	for _, test := range staleTests {
+		assert.Equal(t, test.expected, test.feed.IsStaleAt(now, 24*time.Hour), test.name)
+	}
+
+	// The age is compared against maxAge
+	feed := gofeed.Feed{UpdatedParsed: &weekAgo}
+	assert.False(t, feed.IsStaleAt(now, 8*24*time.Hour))
+
+	// IsStale measures the age at the current time
+	assert.True(t, feed.IsStale(24*time.Hour))
+	recent := time.Now().Add(-time.Hour)
+	feed = gofeed.Feed{UpdatedParsed: &recent}
+	assert.False(t, feed.IsStale(24*time.Hour))
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	items := make(chan *gofeed.Item)
+	go func() {
+		for i := 0; i < 100; i++ {
+			items <- &gofeed.Item{Title: fmt.Sprintf("Item %d", i), Link: "http://example.com/\n"}
+		}
+		close(items)
+	}()
+
+	var buf bytes.Buffer
+	err := gofeed.WriteNDJSON(&buf, items)
+	assert.Nil(t, err)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if assert.Len(t, lines, 100) {
+		for i, line := range lines {
+			item := &gofeed.Item{}
+			assert.Nil(t, json.Unmarshal([]byte(line), item), "line %d is not valid json", i)
+			assert.Equal(t, fmt.Sprintf("Item %d", i), item.Title)
+			assert.Equal(t, "http://example.com/\n", item.Link)
+		}
+	}
+}
+
+func TestFeed_WriteRSS(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/atom10_feed_entries.xml")
+
+	fp := gofeed.NewParser()
+	atom, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+
+	var buf bytes.Buffer
+	err = atom.WriteRSS(&buf)
+	assert.Nil(t, err)
+
+	// The output must be well-formed for strict parsers too
+	d := xml.NewDecoder(bytes.NewReader(buf.Bytes()))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if !assert.Nil(t, err, "invalid xml:\n%s", buf.String()) {
+			return
+		}
+	}
+
+	rss, err := fp.Parse(bytes.NewReader(buf.Bytes()))
+	assert.Nil(t, err)
+	assert.Equal(t, "rss", rss.FeedType)
+	assert.Equal(t, "2.0", rss.FeedVersion)
+	assert.Equal(t, atom.Title, rss.Title)
+	assert.Equal(t, atom.Description, rss.Description)
+	assert.Equal(t, atom.Link, rss.Link)
+	assert.Equal(t, atom.FeedLink, rss.FeedLink)
+	assert.Equal(t, atom.Copyright, rss.Copyright)
+	assert.Equal(t, atom.Author, rss.Author)
+	assert.Equal(t, atom.Categories, rss.Categories)
+	assert.True(t, atom.UpdatedParsed.Equal(*rss.UpdatedParsed))
+
+	if assert.Len(t, rss.Items, 2) {
+		for i, item := range rss.Items {
+			assert.Equal(t, atom.Items[i].Title, item.Title)
+			assert.Equal(t, atom.Items[i].Link, item.Link)
+			assert.Equal(t, atom.Items[i].GUID, item.GUID)
+			assert.Equal(t, atom.Items[i].Categories, item.Categories)
+			assert.Equal(t, atom.Items[i].Enclosures, item.Enclosures)
+		}
+		assert.Equal(t, "Tips & tricks", rss.Items[0].Description)
+		assert.True(t, atom.Items[0].PublishedParsed.Equal(*rss.Items[0].PublishedParsed))
+		assert.Equal(t, "Sun, 01 Mar 2020 08:00:00 +0000", rss.Items[0].Published)
+
+		// Without a summary or published date the content and
+		// updated date stand in
+		assert.Equal(t, "<p>Crisp &amp; light</p>", rss.Items[1].Description)
+		assert.True(t, atom.Items[1].UpdatedParsed.Equal(*rss.Items[1].PublishedParsed))
+	}
+}
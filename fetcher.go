@@ -0,0 +1,232 @@
+package gofeed
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fetcher retrieves the raw content of a feed url.  Setting
+// Parser.Fetcher replaces the Parser's built-in http client,
+// e.g. with a caching layer or a fake for tests.
+type Fetcher interface {
+	Fetch(ctx context.Context, url string) (io.ReadCloser, *ResponseMeta, error)
+}
+
+// ResponseMeta describes the response a feed was read from.
+type ResponseMeta struct {
+	// URL is the final url of the feed, after redirects
+	URL        string
+	StatusCode int
+	Header     http.Header
+	// Robots holds the X-Robots-Tag headers of the response,
+	// e.g. "noindex, nofollow" or "googlebot: noindex"
+	Robots []string
+}
+
+// robotsParameters are the robots directives taking a value
+// after a colon, which a user agent scope could be mistaken for
+var robotsParameters = map[string]bool{
+	"unavailable_after": true,
+	"max-snippet":       true,
+	"max-image-preview": true,
+	"max-video-preview": true,
+}
+
+// NoIndex reports whether the robots directives of the
+// response forbid indexing it, through noindex or none.
+// Directives scoped to a user agent, as in "googlebot: noindex",
+// are ignored.
+func (m *ResponseMeta) NoIndex() bool {
+	if m == nil {
+		return false
+	}
+	for _, robots := range m.Robots {
+		robots = strings.ToLower(robots)
+		if i := strings.Index(robots, ":"); i >= 0 {
+			name := strings.TrimSpace(robots[:i])
+			if !robotsParameters[name] && !strings.Contains(name, ",") {
+				continue
+			}
+		}
+		for _, directive := range strings.Split(robots, ",") {
+			directive = strings.TrimSpace(directive)
+			if directive == "noindex" || directive == "none" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HTTPFetcher is a Fetcher backed by an http.Client.
+// Responses with an error status are returned as an HTTPError.
+type HTTPFetcher struct {
+	// Client issues the requests.  When nil,
+	// http.DefaultClient is used.
+	Client *http.Client
+}
+
+// Fetch issues a GET request for url bound to ctx.
+func (hf *HTTPFetcher) Fetch(ctx context.Context, url string) (io.ReadCloser, *ResponseMeta, error) {
+	client := hf.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	acceptGzip(req)
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+		resp.Body.Close()
+		return nil, responseMeta(resp), HTTPError{
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+		}
+	}
+	if err := decodeBody(resp); err != nil {
+		return nil, nil, err
+	}
+	return resp.Body, responseMeta(resp), nil
+}
+
+// open fetches feedURL with the Parser's Fetcher, or with
+// its own http client when no Fetcher is set.
+func (f *Parser) open(ctx context.Context, feedURL string) (io.ReadCloser, *ResponseMeta, error) {
+	return f.openSince(ctx, feedURL, time.Time{})
+}
+
+// openSince is open with an If-Modified-Since header.  A
+// custom Fetcher has no way to receive it and always fetches.
+func (f *Parser) openSince(ctx context.Context, feedURL string, since time.Time) (io.ReadCloser, *ResponseMeta, error) {
+	if f.Fetcher == nil {
+		resp, err := f.fetch(ctx, feedURL, since)
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp.Body, responseMeta(resp), nil
+	}
+
+	u, err := url.Parse(feedURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := f.countRequest(u.Host); err != nil {
+		return nil, nil, err
+	}
+	return f.Fetcher.Fetch(ctx, feedURL)
+}
+
+// url is the url of the response, empty for a nil meta
+func (m *ResponseMeta) url() string {
+	if m == nil {
+		return ""
+	}
+	return m.URL
+}
+
+func responseMeta(resp *http.Response) *ResponseMeta {
+	meta := &ResponseMeta{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Robots:     resp.Header.Values("X-Robots-Tag"),
+	}
+	if resp.Request != nil {
+		meta.URL = resp.Request.URL.String()
+	}
+	return meta
+}
+
+// acceptGzip asks for a gzip compressed response, unless the
+// request already names its encodings.  Asking for it ourselves
+// stops the transport from decompressing the body, which it does
+// trusting the Content-Encoding header and so fails on servers
+// that label plain bodies as gzip; decodeBody checks instead.
+func acceptGzip(req *http.Request) {
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+}
+
+// decodeBody decompresses the body of a gzip encoded response,
+// unless its magic bytes show it was sent uncompressed anyway.
+func decodeBody(resp *http.Response) error {
+	encoding := strings.TrimSpace(resp.Header.Get("Content-Encoding"))
+	if resp.Uncompressed || !strings.EqualFold(encoding, "gzip") {
+		return nil
+	}
+	body, err := gunzipReader(resp.Body)
+	if err != nil {
+		resp.Body.Close()
+		return err
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{body, resp.Body}
+
+	// As the transport does, drop the headers that describe the
+	// encoded body
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+	return nil
+}
+
+// readLimited calls read with body, enforcing MaxBytes: a
+// response with a larger Content-Length is rejected unread and
+// read fails once more than MaxBytes were read, both with
+// ErrResponseTooLarge.
+func (f *Parser) readLimited(body io.Reader, meta *ResponseMeta, read func(io.Reader) error) error {
+	if f.MaxBytes <= 0 {
+		return read(body)
+	}
+	if meta != nil {
+		if n, err := strconv.ParseInt(meta.Header.Get("Content-Length"), 10, 64); err == nil && n > f.MaxBytes {
+			return ErrResponseTooLarge
+		}
+	}
+	limited := &limitedReader{r: body, n: f.MaxBytes}
+	err := read(limited)
+	if limited.exceeded {
+		return ErrResponseTooLarge
+	}
+	return err
+}
+
+// limitedReader reads at most n bytes from r and fails with
+// ErrResponseTooLarge if r has more.
+type limitedReader struct {
+	r        io.Reader
+	n        int64
+	exceeded bool
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.exceeded {
+		return 0, ErrResponseTooLarge
+	}
+	// Read one byte past the limit to tell a body of exactly n
+	// bytes from a larger one
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	if int64(n) > l.n {
+		l.exceeded = true
+		return int(l.n), ErrResponseTooLarge
+	}
+	l.n -= int64(n)
+	return n, err
+}
@@ -0,0 +1,131 @@
+package gofeed_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/shuyaoyimei/gofeed"
+	"github.com/stretchr/testify/assert"
+)
+
+type mapFetcher map[string]string
+
+func (m mapFetcher) Fetch(ctx context.Context, url string) (io.ReadCloser, *gofeed.ResponseMeta, error) {
+	body, ok := m[url]
+	if !ok {
+		return nil, nil, gofeed.HTTPError{StatusCode: 404, Status: "404 Not Found"}
+	}
+	meta := &gofeed.ResponseMeta{URL: url, StatusCode: 200}
+	return ioutil.NopCloser(bytes.NewReader([]byte(body))), meta, nil
+}
+
+func TestParser_ParseURL_Fetcher(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed.xml")
+
+	fp := gofeed.NewParser()
+	fp.Fetcher = mapFetcher{"http://example.com/feed": string(f)}
+	feed, err := fp.ParseURL("http://example.com/feed")
+	assert.Nil(t, err)
+	assert.Equal(t, "Feed Title", feed.Title)
+
+	feed, err = fp.ParseURL("http://example.com/missing")
+	assert.Nil(t, feed)
+	assert.Equal(t, gofeed.HTTPError{StatusCode: 404, Status: "404 Not Found"}, err)
+
+	// The host budget still applies to a custom Fetcher
+	fp.HostRequestBudget = 2
+	_, err = fp.ParseURL("http://example.com/feed")
+	assert.Nil(t, err)
+	_, err = fp.ParseURL("http://example.com/feed")
+	assert.Nil(t, err)
+	_, err = fp.ParseURL("http://example.com/feed")
+	assert.IsType(t, gofeed.HostBudgetError{}, err)
+}
+
+func TestHTTPFetcher_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/feed", http.StatusMovedPermanently)
+		case "/feed":
+			w.Header().Set("Content-Type", "application/rss+xml")
+			w.Write([]byte("<rss></rss>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	hf := &gofeed.HTTPFetcher{}
+	body, meta, err := hf.Fetch(context.Background(), server.URL+"/old")
+	if assert.Nil(t, err) {
+		defer body.Close()
+		b, _ := ioutil.ReadAll(body)
+		assert.Equal(t, "<rss></rss>", string(b))
+		assert.Equal(t, server.URL+"/feed", meta.URL)
+		assert.Equal(t, 200, meta.StatusCode)
+		assert.Equal(t, "application/rss+xml", meta.Header.Get("Content-Type"))
+	}
+
+	body, meta, err = hf.Fetch(context.Background(), server.URL+"/missing")
+	assert.Nil(t, body)
+	assert.Equal(t, 404, meta.StatusCode)
+	assert.IsType(t, gofeed.HTTPError{}, err)
+}
+
+func TestHTTPFetcher_Fetch_Robots(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/noindex.xml" {
+			w.Header().Add("X-Robots-Tag", "googlebot: nofollow")
+			w.Header().Add("X-Robots-Tag", "noindex")
+		}
+		w.Write([]byte("<urlset></urlset>"))
+	}))
+	defer server.Close()
+
+	hf := &gofeed.HTTPFetcher{}
+	body, meta, err := hf.Fetch(context.Background(), server.URL+"/noindex.xml")
+	if assert.Nil(t, err) {
+		body.Close()
+		assert.Equal(t, []string{"googlebot: nofollow", "noindex"}, meta.Robots)
+		assert.True(t, meta.NoIndex())
+	}
+
+	body, meta, err = hf.Fetch(context.Background(), server.URL+"/sitemap.xml")
+	if assert.Nil(t, err) {
+		body.Close()
+		assert.Nil(t, meta.Robots)
+		assert.False(t, meta.NoIndex())
+	}
+}
+
+func TestResponseMeta_NoIndex(t *testing.T) {
+	tests := []struct {
+		robots  []string
+		noIndex bool
+	}{
+		{nil, false},
+		{[]string{"nofollow"}, false},
+		{[]string{"NoIndex"}, true},
+		{[]string{"none"}, true},
+		{[]string{"nofollow, noindex"}, true},
+		{[]string{"unavailable_after: 25 Jun 2010 15:00:00 PST"}, false},
+		{[]string{"noindex, unavailable_after: 25 Jun 2010 15:00:00 PST"}, true},
+		{[]string{"max-snippet: 20, noindex"}, true},
+		{[]string{"googlebot: noindex"}, false},
+		{[]string{"googlebot: noindex", "otherbot: none"}, false},
+	}
+
+	for _, test := range tests {
+		meta := &gofeed.ResponseMeta{Robots: test.robots}
+		assert.Equal(t, test.noIndex, meta.NoIndex(), "robots %q", test.robots)
+	}
+
+	var meta *gofeed.ResponseMeta
+	assert.False(t, meta.NoIndex())
+}
@@ -0,0 +1,197 @@
+package gofeed
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/shuyaoyimei/gofeed/sitemap"
+)
+
+// IndexState records the children of a sitemap index that were
+// already processed, keyed by child url, along with the lastmod
+// the index listed for them at the time.  It can be serialized
+// (e.g. as JSON) to checkpoint a crawl.
+type IndexState map[string]time.Time
+
+// ParseIndexResumable fetches the sitemap index at indexURL and
+// parses its child sitemaps into a single feed, skipping the
+// children recorded in state unless the index lists a newer
+// lastmod for them.  The returned state holds the children that
+// were processed so far, even when an error stopped the crawl, so
+// it can be passed to a later call to continue where it left off.
+// The crawl is bounded by the Parser's TotalDeadline.
+func (f *Parser) ParseIndexResumable(indexURL string, state IndexState) (*Feed, IndexState, error) {
+	next := IndexState{}
+	for link, lastMod := range state {
+		next[link] = lastMod
+	}
+
+	ctx := context.Background()
+	if f.TotalDeadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.TotalDeadline)
+		defer cancel()
+	}
+
+	index, err := f.fetchSitemap(ctx, indexURL)
+	if err != nil {
+		return nil, next, err
+	}
+
+	// The children are merged into the index and translated once
+	// at the end, so MinItems and the hooks see the whole crawl
+	merged := *index
+	for _, ref := range index.Sitemaps {
+		var lastMod time.Time
+		if ref.LastModParsed != nil {
+			lastMod = *ref.LastModParsed
+		}
+		if done, ok := next[ref.Link]; ok && !lastMod.After(done) {
+			continue
+		}
+
+		if ctx.Err() != nil {
+			feed, err := f.translateCrawl(&merged, indexURL, ctx.Err())
+			return feed, next, err
+		}
+
+		child, err := f.fetchSitemap(ctx, ref.Link)
+		if err != nil {
+			feed, err := f.translateCrawl(&merged, indexURL, err)
+			return feed, next, err
+		}
+		merged.Items = append(merged.Items, child.Items...)
+		next[ref.Link] = lastMod
+	}
+
+	feed, err := f.translateCrawl(&merged, indexURL, nil)
+	return feed, next, err
+}
+
+// IndexCrawl is the outcome of an incremental sitemap index
+// crawl.
+type IndexCrawl struct {
+	// Fetched lists the children that were downloaded and parsed
+	Fetched []string
+	// Skipped lists the children left out as unchanged, either
+	// because their index lastmod did not advance or because the
+	// server answered 304 Not Modified
+	Skipped []string
+	// State records the lastmod of every child processed so far,
+	// to pass to the next crawl
+	State IndexState
+}
+
+// ParseIndexIncremental fetches the sitemap index at indexURL
+// and re-crawls only the children that changed since the times
+// recorded in since, typically the State of a previous crawl.
+// A child whose index lastmod is not newer than its recorded
+// time is skipped without a request; any other child with a
+// recorded time is fetched with If-Modified-Since, and skipped
+// when the server answers 304 Not Modified.  Children without a
+// recorded time are always fetched.  As with ParseIndexResumable,
+// the crawl stops at the first error and the returned crawl holds
+// the progress made so far.
+func (f *Parser) ParseIndexIncremental(indexURL string, since IndexState) (*Feed, *IndexCrawl, error) {
+	crawl := &IndexCrawl{State: IndexState{}}
+	for link, lastMod := range since {
+		crawl.State[link] = lastMod
+	}
+
+	ctx := context.Background()
+	if f.TotalDeadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.TotalDeadline)
+		defer cancel()
+	}
+
+	index, err := f.fetchSitemap(ctx, indexURL)
+	if err != nil {
+		return nil, crawl, err
+	}
+
+	merged := *index
+	for _, ref := range index.Sitemaps {
+		prev, seen := crawl.State[ref.Link]
+		if seen && ref.LastModParsed != nil && !ref.LastModParsed.After(prev) {
+			crawl.Skipped = append(crawl.Skipped, ref.Link)
+			continue
+		}
+
+		if ctx.Err() != nil {
+			feed, err := f.translateCrawl(&merged, indexURL, ctx.Err())
+			return feed, crawl, err
+		}
+
+		// Without an index lastmod, the time of the fetch is
+		// what the next crawl compares against
+		lastMod := f.now().UTC().Truncate(time.Second)
+		if ref.LastModParsed != nil {
+			lastMod = *ref.LastModParsed
+		}
+
+		child, err := f.fetchSitemapSince(ctx, ref.Link, prev)
+		if herr, ok := err.(HTTPError); ok && herr.StatusCode == http.StatusNotModified {
+			crawl.Skipped = append(crawl.Skipped, ref.Link)
+			crawl.State[ref.Link] = lastMod
+			continue
+		}
+		if err != nil {
+			feed, err := f.translateCrawl(&merged, indexURL, err)
+			return feed, crawl, err
+		}
+		merged.Items = append(merged.Items, child.Items...)
+		crawl.Fetched = append(crawl.Fetched, ref.Link)
+		crawl.State[ref.Link] = lastMod
+	}
+
+	feed, err := f.translateCrawl(&merged, indexURL, nil)
+	return feed, crawl, err
+}
+
+// translateCrawl translates the sitemaps merged by a crawl.  An
+// error that stopped the crawl takes precedence over one of the
+// translation, such as too few items, and is returned along
+// with the feed of the urls merged so far when there is one.
+func (f *Parser) translateCrawl(merged *sitemap.Feed, base string, crawlErr error) (*Feed, error) {
+	feed, err := f.translate(f.translator(FeedTypeSitemap), merged, base)
+	if crawlErr != nil {
+		return feed, crawlErr
+	}
+	return feed, err
+}
+
+func (f *Parser) fetchSitemap(ctx context.Context, sitemapURL string) (*sitemap.Feed, error) {
+	return f.fetchSitemapSince(ctx, sitemapURL, time.Time{})
+}
+
+// fetchSitemapSince is fetchSitemap with an If-Modified-Since
+// header, see openSince.
+func (f *Parser) fetchSitemapSince(ctx context.Context, sitemapURL string, since time.Time) (sf *sitemap.Feed, err error) {
+	if err := f.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer f.release()
+
+	body, meta, err := f.openSince(ctx, sitemapURL, since)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		ce := body.Close()
+		if ce != nil && err == nil {
+			err = ce
+		}
+	}()
+
+	err = f.readLimited(body, meta, func(r io.Reader) (err error) {
+		sf, err = f.sitemapParser().Parse(r)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sf, nil
+}
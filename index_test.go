@@ -0,0 +1,276 @@
+package gofeed_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shuyaoyimei/gofeed"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParser_ParseIndexResumable(t *testing.T) {
+	fetched := map[string]int{}
+	broken := true
+	lastMod := "2017-01-01"
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched[r.URL.Path]++
+		switch r.URL.Path {
+		case "/index.xml":
+			fmt.Fprintf(w, `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<sitemap><loc>%[1]s/a.xml</loc><lastmod>%[2]s</lastmod></sitemap>
+<sitemap><loc>%[1]s/b.xml</loc></sitemap>
+<sitemap><loc>%[1]s/c.xml</loc></sitemap>
+</sitemapindex>`, server.URL, lastMod)
+		case "/c.xml":
+			if broken {
+				http.Error(w, "unavailable", http.StatusServiceUnavailable)
+				return
+			}
+			fallthrough
+		default:
+			fmt.Fprintf(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>http://www.example.com%s</loc></url>
+</urlset>`, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	fp := gofeed.NewParser()
+
+	// The crawl stops at the broken child, keeping its progress
+	feed, state, err := fp.ParseIndexResumable(server.URL+"/index.xml", nil)
+	assert.NotNil(t, err)
+	assert.Len(t, feed.Items, 2)
+	assert.Len(t, state, 2)
+	assert.Equal(t, time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), state[server.URL+"/a.xml"])
+
+	// Resuming only fetches the remaining child
+	broken = false
+	feed, state, err = fp.ParseIndexResumable(server.URL+"/index.xml", state)
+	assert.Nil(t, err)
+	assert.Len(t, feed.Items, 1)
+	assert.Equal(t, "http://www.example.com/c.xml", feed.Items[0].Link)
+	assert.Len(t, state, 3)
+	assert.Equal(t, 1, fetched["/a.xml"])
+	assert.Equal(t, 1, fetched["/b.xml"])
+	assert.Equal(t, 2, fetched["/c.xml"])
+
+	// Children with a newer lastmod are fetched again
+	lastMod = "2017-02-01"
+	feed, state, err = fp.ParseIndexResumable(server.URL+"/index.xml", state)
+	assert.Nil(t, err)
+	assert.Len(t, feed.Items, 1)
+	assert.Equal(t, "http://www.example.com/a.xml", feed.Items[0].Link)
+	assert.Equal(t, time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC), state[server.URL+"/a.xml"])
+	assert.Equal(t, 2, fetched["/a.xml"])
+}
+
+func TestParser_ParseIndexResumable_TotalDeadline(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.xml":
+			fmt.Fprintf(w, `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<sitemap><loc>%[1]s/fast.xml</loc></sitemap>
+<sitemap><loc>%[1]s/slow.xml</loc></sitemap>
+<sitemap><loc>%[1]s/never.xml</loc></sitemap>
+</sitemapindex>`, server.URL)
+		case "/slow.xml":
+			// Hang until the client gives up
+			<-r.Context().Done()
+		default:
+			fmt.Fprintf(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>http://www.example.com%s</loc></url>
+</urlset>`, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	fp := gofeed.NewParser()
+	fp.TotalDeadline = 200 * time.Millisecond
+	feed, state, err := fp.ParseIndexResumable(server.URL+"/index.xml", nil)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Len(t, feed.Items, 1)
+	assert.Equal(t, "http://www.example.com/fast.xml", feed.Items[0].Link)
+	assert.Len(t, state, 1)
+}
+
+func TestParser_ParseIndexResumable_MaxBytes(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.xml":
+			// Flushed, so sent without a Content-Length
+			fmt.Fprint(w, `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+			w.(http.Flusher).Flush()
+			fmt.Fprintf(w, "<!-- %s -->", strings.Repeat("padding ", 256))
+			fmt.Fprintf(w, `<sitemap><loc>%s/a.xml</loc></sitemap></sitemapindex>`, server.URL)
+		default:
+			fmt.Fprint(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>http://www.example.com/a</loc></url>
+</urlset>`)
+		}
+	}))
+	defer server.Close()
+
+	// The index is held to MaxBytes like any fetched feed
+	fp := gofeed.NewParser()
+	fp.MaxBytes = 1024
+	_, _, err := fp.ParseIndexResumable(server.URL+"/index.xml", nil)
+	assert.Equal(t, gofeed.ErrResponseTooLarge, err)
+
+	fp.MaxBytes = 4096
+	feed, _, err := fp.ParseIndexResumable(server.URL+"/index.xml", nil)
+	assert.Nil(t, err)
+	assert.Len(t, feed.Items, 1)
+}
+
+func TestParser_ParseIndex_MinItems(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.xml":
+			fmt.Fprintf(w, `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<sitemap><loc>%[1]s/a.xml</loc></sitemap>
+<sitemap><loc>%[1]s/b.xml</loc></sitemap>
+</sitemapindex>`, server.URL)
+		default:
+			fmt.Fprintf(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>http://www.example.com%s</loc></url>
+</urlset>`, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	// MinItems applies to the merged crawl, not to the index
+	// or to each child on its own
+	fp := gofeed.NewParser()
+	fp.MinItems = 2
+	feed, _, err := fp.ParseIndexResumable(server.URL+"/index.xml", nil)
+	assert.Nil(t, err)
+	assert.Len(t, feed.Items, 2)
+	feed, _, err = fp.ParseIndexIncremental(server.URL+"/index.xml", nil)
+	assert.Nil(t, err)
+	assert.Len(t, feed.Items, 2)
+
+	fp.MinItems = 3
+	_, _, err = fp.ParseIndexResumable(server.URL+"/index.xml", nil)
+	assert.Equal(t, gofeed.TooFewItemsError{Items: 2, MinItems: 3}, err)
+	_, _, err = fp.ParseIndexIncremental(server.URL+"/index.xml", nil)
+	assert.Equal(t, gofeed.TooFewItemsError{Items: 2, MinItems: 3}, err)
+}
+
+func TestParser_ParseIndex_Hooks(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.xml":
+			fmt.Fprintf(w, `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<sitemap><loc>%[1]s/a.xml</loc></sitemap>
+<sitemap><loc>%[1]s/b.xml</loc></sitemap>
+</sitemapindex>`, server.URL)
+		default:
+			fmt.Fprintf(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>http://www.example.com%s</loc></url>
+</urlset>`, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	var feeds []*gofeed.Feed
+	items := 0
+	fp := gofeed.NewParser()
+	fp.FeedHook = func(feed *gofeed.Feed) { feeds = append(feeds, feed) }
+	fp.ItemHook = func(*gofeed.Item) { items++ }
+
+	// The hooks run once, on the merged feed that is returned
+	feed, _, err := fp.ParseIndexResumable(server.URL+"/index.xml", nil)
+	assert.Nil(t, err)
+	if assert.Len(t, feeds, 1) {
+		assert.Equal(t, feed, feeds[0])
+	}
+	assert.Equal(t, 2, items)
+
+	feeds, items = nil, 0
+	feed, _, err = fp.ParseIndexIncremental(server.URL+"/index.xml", nil)
+	assert.Nil(t, err)
+	if assert.Len(t, feeds, 1) {
+		assert.Equal(t, feed, feeds[0])
+	}
+	assert.Equal(t, 2, items)
+}
+
+func TestParser_ParseIndexIncremental(t *testing.T) {
+	jan := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	fetched := map[string]int{}
+	modified := map[string]time.Time{
+		"/a.xml": jan,
+		"/b.xml": jan,
+		"/c.xml": jan,
+	}
+	lastMod := "2017-01-01"
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/index.xml":
+			fmt.Fprintf(w, `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<sitemap><loc>%[1]s/a.xml</loc><lastmod>%[2]s</lastmod></sitemap>
+<sitemap><loc>%[1]s/b.xml</loc><lastmod>%[2]s</lastmod></sitemap>
+<sitemap><loc>%[1]s/c.xml</loc></sitemap>
+</sitemapindex>`, server.URL, lastMod)
+		default:
+			fetched[r.URL.Path]++
+			http.ServeContent(w, r, "", modified[r.URL.Path], strings.NewReader(fmt.Sprintf(
+				`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+<url><loc>http://www.example.com%s</loc></url>
+</urlset>`, r.URL.Path)))
+		}
+	}))
+	defer server.Close()
+
+	march := time.Date(2017, 3, 1, 12, 30, 0, 0, time.UTC)
+	fp := gofeed.NewParser()
+	fp.Now = func() time.Time { return march }
+
+	// The first crawl fetches every child
+	feed, crawl, err := fp.ParseIndexIncremental(server.URL+"/index.xml", nil)
+	assert.Nil(t, err)
+	assert.Len(t, feed.Items, 3)
+	assert.Len(t, crawl.Fetched, 3)
+	assert.Empty(t, crawl.Skipped)
+	assert.Equal(t, jan, crawl.State[server.URL+"/a.xml"])
+	// Without an index lastmod the crawl time is kept
+	assert.Equal(t, march, crawl.State[server.URL+"/c.xml"])
+
+	// Only the children whose lastmod advanced are requested, and
+	// the server reports the ones that did not really change
+	lastMod = "2017-02-01"
+	modified["/b.xml"] = jan.AddDate(0, 1, 0)
+	feed, crawl, err = fp.ParseIndexIncremental(server.URL+"/index.xml", crawl.State)
+	assert.Nil(t, err)
+	assert.Len(t, feed.Items, 1)
+	assert.Equal(t, "http://www.example.com/b.xml", feed.Items[0].Link)
+	assert.Equal(t, []string{server.URL + "/b.xml"}, crawl.Fetched)
+	assert.Equal(t, []string{server.URL + "/a.xml", server.URL + "/c.xml"}, crawl.Skipped)
+	assert.Equal(t, 2, fetched["/a.xml"])
+	assert.Equal(t, 2, fetched["/b.xml"])
+	assert.Equal(t, 2, fetched["/c.xml"])
+
+	// Children whose index lastmod did not advance are not requested
+	feed, crawl, err = fp.ParseIndexIncremental(server.URL+"/index.xml", crawl.State)
+	assert.Nil(t, err)
+	assert.Empty(t, feed.Items)
+	assert.Empty(t, crawl.Fetched)
+	assert.Len(t, crawl.Skipped, 3)
+	assert.Equal(t, 2, fetched["/a.xml"])
+	assert.Equal(t, 2, fetched["/b.xml"])
+	assert.Equal(t, 3, fetched["/c.xml"])
+}
@@ -0,0 +1,186 @@
+package shared
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"golang.org/x/text/transform"
+)
+
+// maxEntityLen bounds how far past an '&' the terminating ';'
+// of an entity reference is looked for.
+const maxEntityLen = 32
+
+// NewAmpersandEscaperReader creates an io.Reader that
+// wraps another io.Reader and, as it is read, escapes bare
+// ampersands, those that don't start an entity or character
+// reference, as &amp;.  Ampersands in CDATA sections and
+// comments are left alone, and utf-16 input is passed
+// through unchanged.
+func NewAmpersandEscaperReader(input io.Reader) io.Reader {
+	br := bufio.NewReader(input)
+	head, _ := br.Peek(2)
+	if isUTF16(head) {
+		return br
+	}
+	return transform.NewReader(br, &ampersandEscaper{})
+}
+
+var (
+	cdataStart   = []byte("<![CDATA[")
+	cdataEnd     = []byte("]]>")
+	commentStart = []byte("<!--")
+	commentEnd   = []byte("-->")
+)
+
+// ampersandEscaper is the transform of
+// NewAmpersandEscaperReader.  end is the marker closing the
+// CDATA section or comment being copied, nil outside of them.
+type ampersandEscaper struct {
+	end []byte
+}
+
+func (e *ampersandEscaper) Reset() {
+	e.end = nil
+}
+
+func (e *ampersandEscaper) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	// emit copies b to dst, n bytes of src having been consumed
+	emit := func(b []byte, n int) bool {
+		if len(dst)-nDst < len(b) {
+			return false
+		}
+		nDst += copy(dst[nDst:], b)
+		nSrc += n
+		return true
+	}
+
+	for nSrc < len(src) {
+		rest := src[nSrc:]
+
+		if e.end != nil {
+			if hasPrefix(rest, e.end, atEOF) == prefixShort {
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			if bytes.HasPrefix(rest, e.end) {
+				if !emit(e.end, len(e.end)) {
+					return nDst, nSrc, transform.ErrShortDst
+				}
+				e.end = nil
+				continue
+			}
+			if !emit(rest[:1], 1) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			continue
+		}
+
+		switch rest[0] {
+		case '<':
+			cdata := hasPrefix(rest, cdataStart, atEOF)
+			comment := hasPrefix(rest, commentStart, atEOF)
+			if cdata == prefixShort || comment == prefixShort {
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			if cdata == prefixMatch {
+				if !emit(cdataStart, len(cdataStart)) {
+					return nDst, nSrc, transform.ErrShortDst
+				}
+				e.end = cdataEnd
+				continue
+			}
+			if comment == prefixMatch {
+				if !emit(commentStart, len(commentStart)) {
+					return nDst, nSrc, transform.ErrShortDst
+				}
+				e.end = commentEnd
+				continue
+			}
+		case '&':
+			ref, ok := entityRef(rest)
+			if !ok && !atEOF && len(rest) <= maxEntityLen {
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			if ref == 0 {
+				if !emit([]byte("&amp;"), 1) {
+					return nDst, nSrc, transform.ErrShortDst
+				}
+				continue
+			}
+			if !emit(rest[:ref], ref) {
+				return nDst, nSrc, transform.ErrShortDst
+			}
+			continue
+		}
+
+		if !emit(rest[:1], 1) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+	}
+	return nDst, nSrc, nil
+}
+
+const (
+	prefixNone = iota
+	prefixMatch
+	// prefixShort means b may still turn out to start with
+	// the prefix once more input is read
+	prefixShort
+)
+
+func hasPrefix(b, prefix []byte, atEOF bool) int {
+	if bytes.HasPrefix(b, prefix) {
+		return prefixMatch
+	}
+	if !atEOF && len(b) < len(prefix) && bytes.HasPrefix(prefix, b) {
+		return prefixShort
+	}
+	return prefixNone
+}
+
+// entityRef returns the length of the entity or character
+// reference b starts with, or 0 for a bare ampersand.  ok is
+// false when b ended before that could be decided.
+func entityRef(b []byte) (n int, ok bool) {
+	end := bytes.IndexByte(b, ';')
+	if end < 0 || end > maxEntityLen {
+		return 0, len(b) > maxEntityLen
+	}
+	name := b[1:end]
+	if len(name) == 0 {
+		return 0, true
+	}
+
+	if name[0] == '#' {
+		digits, isDigit := name[1:], isDecimal
+		if len(digits) > 0 && (digits[0] == 'x' || digits[0] == 'X') {
+			digits, isDigit = digits[1:], isHex
+		}
+		if len(digits) == 0 {
+			return 0, true
+		}
+		for _, c := range digits {
+			if !isDigit(c) {
+				return 0, true
+			}
+		}
+		return end + 1, true
+	}
+
+	for i, c := range name {
+		letter := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' || c == ':' || c >= 0x80
+		if !letter && (i == 0 || !isDecimal(c) && c != '.' && c != '-') {
+			return 0, true
+		}
+	}
+	return end + 1, true
+}
+
+func isDecimal(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHex(c byte) bool {
+	return isDecimal(c) || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
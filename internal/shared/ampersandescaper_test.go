@@ -0,0 +1,44 @@
+package shared
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewAmpersandEscaperReader(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{"", ""},
+		{"&", "&amp;"},
+		{"Fish & Chips", "Fish &amp; Chips"},
+		{"http://example.org/?a=1&b=2&c", "http://example.org/?a=1&amp;b=2&amp;c"},
+		{"&amp; &lt; &copy; &#169; &#xA9; &x-y.z;", "&amp; &lt; &copy; &#169; &#xA9; &x-y.z;"},
+		{"&#128512; &#x1F600;", "&#128512; &#x1F600;"},
+		{"&; &#; &#x; &#12a; &1a; &a b;", "&amp;; &amp;#; &amp;#x; &amp;#12a; &amp;1a; &amp;a b;"},
+		{"&" + strings.Repeat("a", 40) + ";", "&amp;" + strings.Repeat("a", 40) + ";"},
+
+		// CDATA sections and comments are copied verbatim
+		{"<![CDATA[a & b]]> & <!-- c & d --> &", "<![CDATA[a & b]]> &amp; <!-- c & d --> &amp;"},
+		{"<![CDATA[a & b", "<![CDATA[a & b"},
+		{"<!-", "<!-"},
+
+		// utf-16 is left alone
+		{"\xff\xfe&\x00", "\xff\xfe&\x00"},
+	}
+
+	for _, test := range tests {
+		b, err := ioutil.ReadAll(NewAmpersandEscaperReader(strings.NewReader(test.input)))
+		assert.Nil(t, err)
+		assert.Equal(t, test.output, string(b), "input %q", test.input)
+
+		b, err = ioutil.ReadAll(NewAmpersandEscaperReader(iotest.OneByteReader(strings.NewReader(test.input))))
+		assert.Nil(t, err)
+		assert.Equal(t, test.output, string(b), "input %q read byte by byte", test.input)
+	}
+}
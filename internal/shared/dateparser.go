@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"unicode"
 )
 
 // DateFormats taken from github.com/mjibson/goread
@@ -188,9 +189,122 @@ func ParseDate(ds string) (t time.Time, err error) {
 	}
 	for _, f := range dateFormats {
 		if t, err = time.Parse(f, d); err == nil {
+			t = applyZoneAbbreviation(t)
+			return
+		}
+	}
+
+	// Retry malformed RFC822 dates in their normalized form
+	n := normalizeRFC822(d)
+	for _, f := range rfc822Formats {
+		if t, err = time.Parse(f, n); err == nil {
+			t = applyZoneAbbreviation(t)
 			return
 		}
 	}
 	err = fmt.Errorf("Failed to parse date: %s", ds)
 	return
 }
+
+// rfc822Formats are the layouts tried on RFC822 dates once
+// normalizeRFC822 dropped their day name.
+var rfc822Formats = []string{
+	"2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 UT",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 -07:00",
+	"2 Jan 2006 15:04:05 -0700 MST",
+	"2 Jan 2006 15:04:05",
+	"2 Jan 2006 15:04 MST",
+	"2 Jan 2006 15:04 UT",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04",
+	"2 Jan 06 15:04:05 MST",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04:05",
+	"2 Jan 2006",
+}
+
+// localMonths maps the non-English month abbreviations found
+// in French, German and Spanish feeds to English ones.
+var localMonths = map[string]string{
+	"janv":    "Jan",
+	"ene":     "Jan",
+	"januar":  "Jan",
+	"févr":    "Feb",
+	"fevr":    "Feb",
+	"februar": "Feb",
+	"mär":     "Mar",
+	"mrz":     "Mar",
+	"märz":    "Mar",
+	"mars":    "Mar",
+	"avr":     "Apr",
+	"abr":     "Apr",
+	"mai":     "May",
+	"juin":    "Jun",
+	"juni":    "Jun",
+	"juil":    "Jul",
+	"juli":    "Jul",
+	"août":    "Aug",
+	"aout":    "Aug",
+	"ago":     "Aug",
+	"sept":    "Sep",
+	"okt":     "Oct",
+	"déc":     "Dec",
+	"dez":     "Dec",
+	"dic":     "Dec",
+}
+
+// normalizeRFC822 rewrites a malformed RFC822 date into the
+// shape of rfc822Formats: the leading day name, which may be
+// localized or misspelled and carries no information, is
+// dropped along with a trailing "(zone)" comment, localized
+// months are translated and whitespace is collapsed.
+func normalizeRFC822(d string) string {
+	if i := strings.LastIndex(d, "("); i > 0 && strings.HasSuffix(d, ")") {
+		d = d[:i]
+	}
+
+	fields := strings.Fields(strings.Replace(d, ",", " ", -1))
+	if len(fields) > 1 && isDayName(fields[0]) && unicode.IsDigit(rune(fields[1][0])) {
+		fields = fields[1:]
+	}
+	if len(fields) > 1 {
+		month := strings.ToLower(strings.TrimSuffix(fields[1], "."))
+		if m, ok := localMonths[month]; ok {
+			fields[1] = m
+		}
+	}
+	return strings.Join(fields, " ")
+}
+
+func isDayName(s string) bool {
+	return strings.TrimFunc(s, func(r rune) bool {
+		return unicode.IsLetter(r) || r == '.'
+	}) == ""
+}
+
+// zoneAbbreviations are the offsets of the timezone
+// abbreviations allowed by RFC822.
+var zoneAbbreviations = map[string]int{
+	"EST": -5 * 60 * 60,
+	"EDT": -4 * 60 * 60,
+	"CST": -6 * 60 * 60,
+	"CDT": -5 * 60 * 60,
+	"MST": -7 * 60 * 60,
+	"MDT": -6 * 60 * 60,
+	"PST": -8 * 60 * 60,
+	"PDT": -7 * 60 * 60,
+}
+
+// applyZoneAbbreviation fixes the offset of a date parsed with
+// a zone abbreviation time.Parse didn't know, which it
+// otherwise treats as UTC.
+func applyZoneAbbreviation(t time.Time) time.Time {
+	name, offset := t.Zone()
+	if zoneOffset, ok := zoneAbbreviations[name]; ok && offset == 0 {
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(),
+			t.Second(), t.Nanosecond(), time.FixedZone(name, zoneOffset))
+	}
+	return t
+}
@@ -0,0 +1,53 @@
+package shared
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		date     string
+		expected string
+	}{
+		{"Mon, 02 Jan 2006 15:04:05 GMT", "2006-01-02T15:04:05Z"},
+		{"Mon, 2 Jan 2006 15:04:05 -0000", "2006-01-02T15:04:05Z"},
+		{"Mon, 02 Jan 2006 15:04:05 UT", "2006-01-02T15:04:05Z"},
+		{"Mon, 02 Jan 2006 15:04:05 Z", "2006-01-02T15:04:05Z"},
+		{"Mon, 2 Jan 2006 15:04 EST", "2006-01-02T20:04:00Z"},
+		{"Mon, 2 Jan 06 15:04:05 +0000", "2006-01-02T15:04:05Z"},
+		{"Mon, 02 Jan 2006 15:04:05 +01:00", "2006-01-02T14:04:05Z"},
+
+		// No or misspelled day names and missing commas
+		{"Mon 2 Jan 2006 15:04:05 +0100", "2006-01-02T14:04:05Z"},
+		{"Mon 2 Jan 2006 15:04:05", "2006-01-02T15:04:05Z"},
+		{"2 Jan 2006 15:04:05 UT", "2006-01-02T15:04:05Z"},
+		{"2 Jan 2006 15:04 GMT", "2006-01-02T15:04:00Z"},
+		{"Thurs, 05 Jan 2006 15:04:05 GMT", "2006-01-05T15:04:05Z"},
+		{"Mon,  2 Jan 2006  15:04:05 GMT", "2006-01-02T15:04:05Z"},
+		{"Mon, 02 Jan 2006 15:04:05 +0000 (UTC)", "2006-01-02T15:04:05Z"},
+
+		// Localized day and month names
+		{"Do, 02 Mär 2006 15:04:05 +0100", "2006-03-02T14:04:05Z"},
+		{"mar., 02 mai 2006 15:04:05 +0200", "2006-05-02T13:04:05Z"},
+		{"Lun, 02 Ene 2006 15:04:05 GMT", "2006-01-02T15:04:05Z"},
+		{"Mo, 02 Okt 2006 15:04:05 +0200", "2006-10-02T13:04:05Z"},
+		{"Sáb, 02 Dic 2006 15:04:05 GMT", "2006-12-02T15:04:05Z"},
+		{"Sat, 02 Sept 2006 15:04:05 GMT", "2006-09-02T15:04:05Z"},
+
+		{"", ""},
+		{"yesterday", ""},
+		{"Mon, 32 Jan 2006 15:04:05 GMT", ""},
+	}
+
+	for _, test := range tests {
+		date, err := ParseDate(test.date)
+		if test.expected == "" {
+			assert.NotNil(t, err, "%q should not have parsed", test.date)
+		} else if assert.Nil(t, err, "%q did not parse", test.date) {
+			assert.Equal(t, test.expected, date.UTC().Format(time.RFC3339), "%q", test.date)
+		}
+	}
+}
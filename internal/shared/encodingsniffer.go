@@ -0,0 +1,70 @@
+package shared
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+var utf8BOM = []byte("\xef\xbb\xbf")
+
+// NewEncodingSnifferReader creates an io.Reader that
+// wraps another io.Reader and fixes documents that claim
+// to be utf-8, by their xml declaration or for lack of one,
+// but aren't valid utf-8.  Their encoding is sniffed, which
+// for stray high bytes means windows-1252, and the document
+// is converted to utf-8 with its declaration rewritten to
+// match.  Valid utf-8 documents, those with a utf-8 byte
+// order mark and those declaring another encoding are passed
+// through unchanged.  The whole input is read on the first
+// Read, as invalid bytes may be found anywhere in it.
+func NewEncodingSnifferReader(input io.Reader) io.Reader {
+	return &encodingSniffer{input: input}
+}
+
+type encodingSniffer struct {
+	input io.Reader
+	r     io.Reader
+}
+
+func (es *encodingSniffer) Read(p []byte) (int, error) {
+	if es.r == nil {
+		b, err := ioutil.ReadAll(es.input)
+		if err != nil {
+			return 0, err
+		}
+		es.r = bytes.NewReader(sniffEncoding(b))
+	}
+	return es.r.Read(p)
+}
+
+// sniffEncoding returns b converted to utf-8 when it claims
+// to be utf-8 and isn't, and b itself otherwise.
+func sniffEncoding(b []byte) []byte {
+	if bytes.HasPrefix(b, utf8BOM) || !isUTF8Document(b) || utf8.Valid(b) {
+		return b
+	}
+
+	// DetermineEncoding only looks at the start of the
+	// document, which may well be plain ascii.
+	e, name, _ := charset.DetermineEncoding(b, "")
+	if name == "utf-8" {
+		e, _ = charset.Lookup("windows-1252")
+	}
+	decoded, err := e.NewDecoder().Bytes(b)
+	if err != nil {
+		return b
+	}
+
+	if m := encodingDecl.FindSubmatchIndex(decoded); m != nil {
+		fixed := make([]byte, 0, len(decoded))
+		fixed = append(fixed, decoded[:m[2]]...)
+		fixed = append(fixed, "utf-8"...)
+		fixed = append(fixed, decoded[m[3]:]...)
+		decoded = fixed
+	}
+	return decoded
+}
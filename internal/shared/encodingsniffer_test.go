@@ -0,0 +1,38 @@
+package shared
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEncodingSnifferReader(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{"", ""},
+		{"<rss>caf\xc3\xa9</rss>", "<rss>café</rss>"},
+		{"<rss>caf\xe9</rss>", "<rss>café</rss>"},
+		{`<?xml version="1.0" encoding="UTF-8"?><rss>caf` + "\xe9 \x92s</rss>", `<?xml version="1.0" encoding="utf-8"?><rss>café ’s</rss>`},
+		{`<?xml version='1.0' encoding='utf8'?><rss>` + strings.Repeat("a", 2048) + "\xe9</rss>", `<?xml version='1.0' encoding='utf-8'?><rss>` + strings.Repeat("a", 2048) + "é</rss>"},
+
+		// Other encodings are left to the charset conversion
+		{`<?xml version="1.0" encoding="ISO-8859-1"?><rss>caf` + "\xe9</rss>", `<?xml version="1.0" encoding="ISO-8859-1"?><rss>caf` + "\xe9</rss>"},
+		{"\xef\xbb\xbf<rss>caf\xe9</rss>", "\xef\xbb\xbf<rss>caf\xe9</rss>"},
+		{"\xff\xfe<\x00r\x00", "\xff\xfe<\x00r\x00"},
+	}
+
+	for _, test := range tests {
+		b, err := ioutil.ReadAll(NewEncodingSnifferReader(strings.NewReader(test.input)))
+		assert.Nil(t, err)
+		assert.Equal(t, test.output, string(b), "input %q", test.input)
+
+		b, err = ioutil.ReadAll(NewEncodingSnifferReader(iotest.OneByteReader(strings.NewReader(test.input))))
+		assert.Nil(t, err)
+		assert.Equal(t, test.output, string(b), "input %q read a byte at a time", test.input)
+	}
+}
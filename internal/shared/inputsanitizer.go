@@ -0,0 +1,65 @@
+package shared
+
+import (
+	"bufio"
+	"io"
+
+	"golang.org/x/text/transform"
+)
+
+// NewInputSanitizerReader creates an io.Reader that
+// wraps another io.Reader and, as it is read, strips null
+// bytes and normalizes \r\n and lone \r line endings to \n.
+// It works on bytes, so it is safe for any ascii compatible
+// encoding; utf-16 input, where null bytes are part of the
+// characters, is passed through unchanged.
+func NewInputSanitizerReader(input io.Reader) io.Reader {
+	br := bufio.NewReader(input)
+	head, _ := br.Peek(2)
+	if isUTF16(head) {
+		return br
+	}
+	return transform.NewReader(br, inputSanitizer{})
+}
+
+// isUTF16 reports whether a document starts with a utf-16
+// byte order mark or with an utf-16 encoded '<'.
+func isUTF16(head []byte) bool {
+	if len(head) < 2 {
+		return false
+	}
+	switch string(head[:2]) {
+	case "\xfe\xff", "\xff\xfe", "<\x00", "\x00<":
+		return true
+	}
+	return false
+}
+
+type inputSanitizer struct{ transform.NopResetter }
+
+func (inputSanitizer) Transform(dst, src []byte, atEOF bool) (nDst, nSrc int, err error) {
+	for nSrc < len(src) {
+		c := src[nSrc]
+		if c == 0 {
+			nSrc++
+			continue
+		}
+		if c == '\r' {
+			// A \r at the end of src may be the start of a \r\n
+			if nSrc+1 == len(src) && !atEOF {
+				return nDst, nSrc, transform.ErrShortSrc
+			}
+			c = '\n'
+			if nSrc+1 < len(src) && src[nSrc+1] == '\n' {
+				nSrc++
+			}
+		}
+		if nDst == len(dst) {
+			return nDst, nSrc, transform.ErrShortDst
+		}
+		dst[nDst] = c
+		nDst++
+		nSrc++
+	}
+	return nDst, nSrc, nil
+}
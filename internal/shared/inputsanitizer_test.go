@@ -0,0 +1,38 @@
+package shared
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewInputSanitizerReader(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{"", ""},
+		{"<rss>\r\n<channel>\r\n", "<rss>\n<channel>\n"},
+		{"a\rb\r\r\nc\r", "a\nb\n\nc\n"},
+		{"<ti\x00tle>Feed\x00\x00 Title</title>", "<title>Feed Title</title>"},
+		{"caf\xe9\x00", "caf\xe9"},
+
+		// utf-16 is left alone
+		{"\xff\xfe<\x00\r\x00\n\x00", "\xff\xfe<\x00\r\x00\n\x00"},
+		{"<\x00r\x00", "<\x00r\x00"},
+	}
+
+	for _, test := range tests {
+		b, err := ioutil.ReadAll(NewInputSanitizerReader(strings.NewReader(test.input)))
+		assert.Nil(t, err)
+		assert.Equal(t, test.output, string(b), "input %q", test.input)
+
+		// A \r\n split across reads is still a single line ending
+		b, err = ioutil.ReadAll(NewInputSanitizerReader(iotest.OneByteReader(strings.NewReader(test.input))))
+		assert.Nil(t, err)
+		assert.Equal(t, test.output, string(b), "input %q read byte by byte", test.input)
+	}
+}
@@ -0,0 +1,87 @@
+package shared
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// junkPeekSize is how far past a '<' is looked to tell the
+// start of a document from a stray '<'
+const junkPeekSize = 64
+
+// feedRoots are the root elements a document may start with
+// once leading junk is skipped
+var feedRoots = []string{"rss", "rdf", "feed", "urlset", "sitemapindex", "opml"}
+
+// NewLeadingJunkSkipperReader creates an io.Reader that
+// wraps another io.Reader and drops anything before the
+// start of the xml document, such as http headers prepended
+// by a misconfigured proxy.  The document is taken to start
+// at the first xml declaration, comment, doctype or feed root
+// element.
+func NewLeadingJunkSkipperReader(input io.Reader) io.Reader {
+	return &junkSkipper{br: bufio.NewReader(input)}
+}
+
+type junkSkipper struct {
+	br      *bufio.Reader
+	started bool
+}
+
+func (js *junkSkipper) Read(p []byte) (int, error) {
+	if !js.started {
+		if err := js.skip(); err != nil {
+			return 0, err
+		}
+		js.started = true
+	}
+	return js.br.Read(p)
+}
+
+// skip discards the input up to the start of the document
+func (js *junkSkipper) skip() error {
+	for {
+		head, err := js.br.Peek(junkPeekSize)
+		if len(head) == 0 {
+			return err
+		}
+		i := bytes.IndexByte(head, '<')
+		if i < 0 {
+			js.br.Discard(len(head))
+			continue
+		}
+		js.br.Discard(i)
+
+		head, _ = js.br.Peek(junkPeekSize)
+		if isDocumentStart(head) {
+			return nil
+		}
+		js.br.Discard(1)
+	}
+}
+
+// isDocumentStart reports whether b, which starts with a '<',
+// begins an xml declaration, comment, doctype or feed root.
+func isDocumentStart(b []byte) bool {
+	if bytes.HasPrefix(b, []byte("<?xml")) || bytes.HasPrefix(b, []byte("<!")) {
+		return true
+	}
+
+	name := b[1:]
+	if end := bytes.IndexAny(name, " \t\r\n/>"); end >= 0 {
+		name = name[:end]
+	} else {
+		return false
+	}
+	// Drop the prefix of names such as rdf:RDF
+	if i := bytes.IndexByte(name, ':'); i >= 0 {
+		name = name[i+1:]
+	}
+	for _, root := range feedRoots {
+		if bytes.EqualFold(name, []byte(root)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package shared
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLeadingJunkSkipperReader(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{"", ""},
+		{"no document", ""},
+		{"<rss></rss>", "<rss></rss>"},
+		{"HTTP/1.1 200 OK\r\n\r\n<?xml version=\"1.0\"?><rss/>", "<?xml version=\"1.0\"?><rss/>"},
+		{"Link: <http://example.org/>; rel=\"self\"\r\n\r\n<feed xmlns=\"http://www.w3.org/2005/Atom\">", "<feed xmlns=\"http://www.w3.org/2005/Atom\">"},
+		{"junk <b>bold</b>\n<!-- generated -->\n<urlset>", "<!-- generated -->\n<urlset>"},
+		{"junk<rdf:RDF>", "<rdf:RDF>"},
+		{strings.Repeat("x <y ", 100) + "<opml>", "<opml>"},
+		{"<rss", ""},
+	}
+
+	for _, test := range tests {
+		b, err := ioutil.ReadAll(NewLeadingJunkSkipperReader(strings.NewReader(test.input)))
+		assert.Nil(t, err)
+		assert.Equal(t, test.output, string(b), "input %q", test.input)
+
+		b, err = ioutil.ReadAll(NewLeadingJunkSkipperReader(iotest.OneByteReader(strings.NewReader(test.input))))
+		assert.Nil(t, err)
+		assert.Equal(t, test.output, string(b), "input %q read byte by byte", test.input)
+	}
+}
@@ -0,0 +1,23 @@
+package shared
+
+import (
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// NormalizeLanguage canonicalizes a language code to its
+// BCP-47 form (e.g. "EN_us" becomes "en-US").  Codes that
+// aren't well-formed are returned trimmed but otherwise as is.
+func NormalizeLanguage(lang string) string {
+	lang = strings.TrimSpace(lang)
+	if lang == "" {
+		return lang
+	}
+
+	tag, err := language.Parse(lang)
+	if err != nil {
+		return lang
+	}
+	return tag.String()
+}
@@ -0,0 +1,31 @@
+package shared
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeLanguage(t *testing.T) {
+	tests := []struct {
+		lang string
+		res  string
+	}{
+		{"", ""},
+		{"en", "en"},
+		{"EN", "en"},
+		{"en-US", "en-US"},
+		{"en-us", "en-US"},
+		{"zh_CN", "zh-CN"},
+		{" pt_br ", "pt-BR"},
+		{"zh-hant-tw", "zh-Hant-TW"},
+		{"english", "english"},
+	}
+
+	for _, test := range tests {
+		res := NormalizeLanguage(test.lang)
+		assert.Equal(t, test.res, res,
+			"%q was normalized to %q instead of %q",
+			test.lang, res, test.res)
+	}
+}
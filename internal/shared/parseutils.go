@@ -4,11 +4,13 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/mmcdole/goxpp"
+	"golang.org/x/net/html"
 )
 
 var (
@@ -92,6 +94,13 @@ func ParseText(p *xpp.XMLPullParser) (string, error) {
 	return DecodeEntities(result)
 }
 
+// CollapseWhitespace trims leading and trailing whitespace
+// and collapses the internal runs of whitespace of a string
+// into single spaces.
+func CollapseWhitespace(str string) string {
+	return strings.Join(strings.Fields(str), " ")
+}
+
 // DecodeEntities decodes escaped XML entities
 // in a string and returns the unescaped string
 func DecodeEntities(str string) (string, error) {
@@ -194,3 +203,35 @@ func ParseNameAddress(nameAddressText string) (name string, address string) {
 	}
 	return
 }
+
+// StripHTML removes the html tags of a string and unescapes
+// its entities, returning the plain text.
+func StripHTML(str string) string {
+	if !strings.ContainsAny(str, "<&") {
+		return str
+	}
+
+	var buf bytes.Buffer
+	z := html.NewTokenizer(strings.NewReader(str))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return strings.TrimSpace(buf.String())
+		case html.TextToken:
+			buf.Write(z.Text())
+		}
+	}
+}
+
+// ResolveURL resolves link against base, returning link
+// unchanged when there is no base or it can't be parsed.
+func ResolveURL(base *url.URL, link string) string {
+	if base == nil || link == "" {
+		return link
+	}
+	rel, err := url.Parse(strings.TrimSpace(link))
+	if err != nil {
+		return link
+	}
+	return base.ResolveReference(rel).String()
+}
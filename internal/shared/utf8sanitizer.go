@@ -0,0 +1,44 @@
+package shared
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+)
+
+var encodingDecl = regexp.MustCompile(`^(?:\xef\xbb\xbf)?\s*<\?xml[^>]*encoding\s*=\s*["']([^"']+)["']`)
+
+// NewUTF8SanitizerReader creates an io.Reader that
+// wraps another io.Reader and replaces invalid utf-8
+// byte sequences with U+FFFD as they are read.  Documents
+// whose xml declaration names an encoding other than
+// utf-8, or that are utf-16 encoded, are
+// passed through for the charset conversion to handle.
+func NewUTF8SanitizerReader(input io.Reader) io.Reader {
+	br := bufio.NewReader(input)
+	head, _ := br.Peek(512)
+	if !isUTF8Document(head) {
+		return br
+	}
+	return transform.NewReader(br, runes.ReplaceIllFormed())
+}
+
+func isUTF8Document(head []byte) bool {
+	if isUTF16(head) {
+		return false
+	}
+
+	m := encodingDecl.FindSubmatch(head)
+	if m == nil {
+		return true
+	}
+	switch strings.ToLower(string(m[1])) {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package shared
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewUTF8SanitizerReader(t *testing.T) {
+	tests := []struct {
+		input  string
+		output string
+	}{
+		{"", ""},
+		{"<rss>caf\xc3\xa9</rss>", "<rss>café</rss>"},
+		{"<rss>caf\xe9 \xff\xfe</rss>", "<rss>caf� ��</rss>"},
+		{`<?xml version="1.0" encoding="UTF-8"?><rss>a` + "\xc3</rss>", `<?xml version="1.0" encoding="UTF-8"?><rss>a` + "�</rss>"},
+
+		// Other encodings are left to the charset conversion
+		{`<?xml version="1.0" encoding="ISO-8859-1"?><rss>caf` + "\xe9</rss>", `<?xml version="1.0" encoding="ISO-8859-1"?><rss>caf` + "\xe9</rss>"},
+		{"\xff\xfe<\x00r\x00", "\xff\xfe<\x00r\x00"},
+	}
+
+	for _, test := range tests {
+		b, err := ioutil.ReadAll(NewUTF8SanitizerReader(strings.NewReader(test.input)))
+		assert.Nil(t, err)
+		assert.Equal(t, test.output, string(b), "input %q", test.input)
+	}
+}
@@ -0,0 +1,102 @@
+package gofeed
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// FetchMetrics are the timings of a feed request made by the
+// Parser's http client, summed over any redirects followed.
+// Phases skipped by a reused connection are zero.
+type FetchMetrics struct {
+	// URL is the final url of the feed, after redirects
+	URL        string
+	StatusCode int
+
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	// FirstByte is the time from writing the final request to
+	// the first byte of its response, excluding DNS, Connect and TLS
+	FirstByte time.Duration
+	// Total is the time from the start of the fetch until the
+	// response body was read and closed
+	Total time.Duration
+}
+
+// fetchTrace collects the FetchMetrics of a request through
+// an httptrace.ClientTrace.
+type fetchTrace struct {
+	mu                               sync.Mutex
+	start                            time.Time
+	dnsStart, connectStart, tlsStart time.Time
+	// wroteRequest is when the latest request was written
+	wroteRequest time.Time
+	metrics      FetchMetrics
+}
+
+func newFetchTrace() *fetchTrace {
+	return &fetchTrace{start: time.Now()}
+}
+
+func (t *fetchTrace) clientTrace() *httptrace.ClientTrace {
+	// since records the time spent since *start into *d
+	since := func(start *time.Time, d *time.Duration) {
+		t.mu.Lock()
+		if !start.IsZero() {
+			*d += time.Since(*start)
+			*start = time.Time{}
+		}
+		t.mu.Unlock()
+	}
+	mark := func(start *time.Time) {
+		t.mu.Lock()
+		*start = time.Now()
+		t.mu.Unlock()
+	}
+
+	return &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { mark(&t.dnsStart) },
+		DNSDone:           func(httptrace.DNSDoneInfo) { since(&t.dnsStart, &t.metrics.DNS) },
+		ConnectStart:      func(string, string) { mark(&t.connectStart) },
+		ConnectDone:       func(string, string, error) { since(&t.connectStart, &t.metrics.Connect) },
+		TLSHandshakeStart: func() { mark(&t.tlsStart) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { since(&t.tlsStart, &t.metrics.TLS) },
+		WroteRequest:      func(httptrace.WroteRequestInfo) { mark(&t.wroteRequest) },
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			t.metrics.FirstByte = time.Since(t.wroteRequest)
+			t.mu.Unlock()
+		},
+	}
+}
+
+// done completes the metrics of the final response
+func (t *fetchTrace) done(resp *http.Response) FetchMetrics {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	m := t.metrics
+	m.StatusCode = resp.StatusCode
+	if resp.Request != nil {
+		m.URL = resp.Request.URL.String()
+	}
+	m.Total = time.Since(t.start)
+	return m
+}
+
+// tracedBody reports the metrics of its response once closed
+type tracedBody struct {
+	io.ReadCloser
+	once   sync.Once
+	report func()
+}
+
+func (b *tracedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.report)
+	return err
+}
@@ -0,0 +1,23 @@
+package gofeed
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// WriteNDJSON writes the items received on items to w as
+// newline delimited JSON, one item per line, until the channel
+// is closed.  Items are written as they arrive, so a feed never
+// has to be held in memory as a whole.  After a write error the
+// channel is still drained, so its sender isn't left blocked.
+func WriteNDJSON(w io.Writer, items <-chan *Item) error {
+	enc := json.NewEncoder(w)
+	for item := range items {
+		if err := enc.Encode(item); err != nil {
+			for range items {
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package opml
+
+import (
+	"encoding/json"
+)
+
+// Outline is an entry of an OPML subscription list.  Feed
+// subscriptions carry an XMLURL, folders only hold Children.
+type Outline struct {
+	Title    string     `json:"title,omitempty"`
+	Text     string     `json:"text,omitempty"`
+	Type     string     `json:"type,omitempty"`
+	XMLURL   string     `json:"xmlUrl,omitempty"`
+	HTMLURL  string     `json:"htmlUrl,omitempty"`
+	Children []*Outline `json:"children,omitempty"`
+}
+
+func (o Outline) String() string {
+	json, _ := json.MarshalIndent(o, "", "    ")
+	return string(json)
+}
@@ -0,0 +1,157 @@
+package opml
+
+import (
+	"io"
+	"strings"
+
+	"github.com/mmcdole/goxpp"
+	"github.com/shuyaoyimei/gofeed/internal/shared"
+)
+
+// Parser is an OPML Parser
+type Parser struct {
+	// CharsetReader converts the input of a non utf-8 document
+	// to utf-8.  When nil, shared.NewReaderLabel is used.
+	CharsetReader func(charset string, input io.Reader) (io.Reader, error)
+}
+
+// Parse parses an OPML document into the outlines of its body
+func (op *Parser) Parse(doc io.Reader) ([]*Outline, error) {
+	charsetReader := op.CharsetReader
+	if charsetReader == nil {
+		charsetReader = shared.NewReaderLabel
+	}
+	p := xpp.NewXMLPullParser(doc, false, charsetReader)
+
+	_, err := shared.FindRoot(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return op.parseRoot(p)
+}
+
+func (op *Parser) parseRoot(p *xpp.XMLPullParser) ([]*Outline, error) {
+	if err := p.Expect(xpp.StartTag, "opml"); err != nil {
+		return nil, err
+	}
+
+	outlines := []*Outline{}
+
+	for {
+		tok, err := shared.NextTag(p)
+		if err != nil {
+			return nil, err
+		}
+
+		if tok == xpp.EndTag {
+			break
+		}
+
+		if tok == xpp.StartTag {
+			if strings.ToLower(p.Name) == "body" {
+				outlines, err = op.parseBody(p)
+				if err != nil {
+					return nil, err
+				}
+			} else {
+				p.Skip()
+			}
+		}
+	}
+
+	if err := p.Expect(xpp.EndTag, "opml"); err != nil {
+		return nil, err
+	}
+
+	return outlines, nil
+}
+
+func (op *Parser) parseBody(p *xpp.XMLPullParser) ([]*Outline, error) {
+	if err := p.Expect(xpp.StartTag, "body"); err != nil {
+		return nil, err
+	}
+
+	outlines, err := op.parseOutlines(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.Expect(xpp.EndTag, "body"); err != nil {
+		return nil, err
+	}
+
+	return outlines, nil
+}
+
+// parseOutlines parses the outline children of the current
+// element, stopping at its end tag.
+func (op *Parser) parseOutlines(p *xpp.XMLPullParser) ([]*Outline, error) {
+	outlines := []*Outline{}
+
+	for {
+		tok, err := shared.NextTag(p)
+		if err != nil {
+			return nil, err
+		}
+
+		if tok == xpp.EndTag {
+			break
+		}
+
+		if tok == xpp.StartTag {
+			if strings.ToLower(p.Name) == "outline" {
+				outline, err := op.parseOutline(p)
+				if err != nil {
+					return nil, err
+				}
+				outlines = append(outlines, outline)
+			} else {
+				p.Skip()
+			}
+		}
+	}
+
+	return outlines, nil
+}
+
+func (op *Parser) parseOutline(p *xpp.XMLPullParser) (*Outline, error) {
+	if err := p.Expect(xpp.StartTag, "outline"); err != nil {
+		return nil, err
+	}
+
+	outline := &Outline{}
+	for _, attr := range p.Attrs {
+		// Attribute names are matched case-insensitively as
+		// exporters disagree on xmlUrl vs xmlurl.
+		switch strings.ToLower(attr.Name.Local) {
+		case "title":
+			outline.Title = attr.Value
+		case "text":
+			outline.Text = attr.Value
+		case "type":
+			outline.Type = attr.Value
+		case "xmlurl":
+			outline.XMLURL = strings.TrimSpace(attr.Value)
+		case "htmlurl":
+			outline.HTMLURL = strings.TrimSpace(attr.Value)
+		}
+	}
+	if outline.Title == "" {
+		outline.Title = outline.Text
+	}
+
+	children, err := op.parseOutlines(p)
+	if err != nil {
+		return nil, err
+	}
+	if len(children) > 0 {
+		outline.Children = children
+	}
+
+	if err := p.Expect(xpp.EndTag, "outline"); err != nil {
+		return nil, err
+	}
+
+	return outline, nil
+}
@@ -0,0 +1,62 @@
+package opml_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/shuyaoyimei/gofeed/opml"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParser_Parse(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/opml/opml_nested.xml")
+
+	fp := &opml.Parser{}
+	outlines, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+
+	expected := []*opml.Outline{
+		{
+			Title: "News",
+			Text:  "News",
+			Children: []*opml.Outline{
+				{
+					Title:   "Example News",
+					Text:    "Example News",
+					Type:    "rss",
+					XMLURL:  "http://www.example.com/news.xml",
+					HTMLURL: "http://www.example.com/",
+				},
+				{
+					Title: "Local",
+					Text:  "Local",
+					Children: []*opml.Outline{
+						{
+							Title:  "City Paper",
+							Text:   "City Paper",
+							Type:   "rss",
+							XMLURL: "http://city.example.com/feed",
+						},
+					},
+				},
+			},
+		},
+		{
+			Title:   "The Example Blog",
+			Text:    "Example Blog",
+			Type:    "rss",
+			XMLURL:  "http://blog.example.com/atom.xml",
+			HTMLURL: "http://blog.example.com/",
+		},
+	}
+	assert.Equal(t, expected, outlines)
+}
+
+func TestParser_ParseWrongRoot(t *testing.T) {
+	fp := &opml.Parser{}
+	outlines, err := fp.Parse(strings.NewReader("<rss><channel></channel></rss>"))
+	assert.Nil(t, outlines)
+	assert.NotNil(t, err)
+}
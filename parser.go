@@ -2,16 +2,26 @@ package gofeed
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha1"
+	"crypto/tls"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/shuyaoyimei/gofeed/atom"
+	"github.com/shuyaoyimei/gofeed/internal/shared"
+	"github.com/shuyaoyimei/gofeed/opml"
 	"github.com/shuyaoyimei/gofeed/rss"
 	"github.com/shuyaoyimei/gofeed/sitemap"
 )
@@ -26,6 +36,32 @@ func (err HTTPError) Error() string {
 	return fmt.Sprintf("http error: %s", err.Status)
 }
 
+// HostBudgetError is returned when a request would exceed
+// the Parser's HostRequestBudget for a host.
+type HostBudgetError struct {
+	Host   string
+	Budget int
+}
+
+func (err HostBudgetError) Error() string {
+	return fmt.Sprintf("request budget of %d exceeded for host %s", err.Budget, err.Host)
+}
+
+// ErrResponseTooLarge is returned when a feed fetched from a
+// url is larger than the Parser's MaxBytes.
+var ErrResponseTooLarge = errors.New("response exceeds the maximum size")
+
+// TooFewItemsError is returned when a feed parsed with fewer
+// items than the Parser's MinItems.
+type TooFewItemsError struct {
+	Items    int
+	MinItems int
+}
+
+func (err TooFewItemsError) Error() string {
+	return fmt.Sprintf("feed has %d items, fewer than the minimum of %d", err.Items, err.MinItems)
+}
+
 // Parser is a universal feed parser that detects
 // a given feed type, parsers it, and translates it
 // to the universal feed type.
@@ -34,17 +70,196 @@ type Parser struct {
 	RSSTranslator     Translator
 	SitemapTranslator Translator
 	Client            *http.Client
-	rp                *rss.Parser
-	ap                *atom.Parser
-	sp                *sitemap.Parser
+
+	// Translators registers the Translator of each FeedType,
+	// taking precedence over AtomTranslator, RSSTranslator and
+	// SitemapTranslator.  Registering one for FeedTypeOPML lets
+	// Parse translate OPML documents, handing it the parsed
+	// []*opml.Outline.
+	Translators map[FeedType]Translator
+
+	// SourceDetector decides how ParseAny treats its source
+	// string.  When nil, DetectSourceType is used.
+	SourceDetector func(source string) SourceType
+
+	// DialContext, when set, is used by the Parser's http
+	// transports to open connections.  It can be used to pin
+	// DNS or to refuse connections to disallowed addresses.
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// Fetcher, when set, retrieves the feeds of ParseURL in
+	// place of the Parser's own http client, so Client,
+	// DialContext, TLSConfig and AcceptLanguage don't apply.
+	Fetcher Fetcher
+
+	// TLSConfig, when set, is used by the Parser's http
+	// transports, e.g. to present client certificates or trust
+	// a private certificate pool.  Setting InsecureSkipVerify
+	// disables certificate checks entirely and leaves feed
+	// requests open to interception; only use it against hosts
+	// on a network you control.
+	TLSConfig *tls.Config
+
+	// CharsetReader converts the input of a non utf-8 feed
+	// to utf-8 for both feed detection and the rss, atom and
+	// sitemap parsers.  When nil, the default charset handling
+	// is used.
+	CharsetReader func(charset string, input io.Reader) (io.Reader, error)
+
+	// SanitizeInvalidUTF8 replaces invalid utf-8 byte sequences
+	// in the input with U+FFFD before parsing, so a few stray
+	// bytes don't make the whole feed fail.  Feeds declaring
+	// another encoding are left to the charset conversion.
+	SanitizeInvalidUTF8 bool
+
+	// SniffEncoding detects the actual encoding of feeds that
+	// claim to be utf-8 but aren't, typically windows-1252 or
+	// latin-1 bytes under a utf-8 declaration, and converts them
+	// to utf-8 before parsing.  It takes precedence over
+	// SanitizeInvalidUTF8, which only sees what is still invalid
+	// afterwards.  The whole feed is buffered to check it.
+	SniffEncoding bool
+
+	// SanitizeInput strips null bytes and normalizes \r\n and
+	// lone \r line endings to \n before parsing, for feeds
+	// served with stray bytes that break the xml parser.
+	SanitizeInput bool
+
+	// LenientXML escapes bare ampersands, such as the ones in
+	// unescaped query strings, before parsing.  Ampersands that
+	// start an entity or character reference are kept.
+	LenientXML bool
+
+	// SkipLeadingJunk drops anything before the start of the
+	// xml document, such as http headers a misconfigured proxy
+	// prepended to the body.  It is off by default as it can
+	// hide a response that isn't a feed at all.
+	SkipLeadingJunk bool
+
+	// AcceptLanguage, when set, is sent as the Accept-Language
+	// header on feed requests so servers doing content
+	// negotiation return the preferred language.
+	AcceptLanguage string
+
+	// AllowLocalFiles lets ParseURL read file:// urls from the
+	// local filesystem.  It is off by default so urls taken from
+	// untrusted input can't be used to read local files.
+	AllowLocalFiles bool
+
+	// ResolveRelativeLinks resolves relative item links to
+	// absolute urls, first against the xml:base in scope and
+	// then, for feeds read with ParseURL and its variants,
+	// against the url the feed was fetched from.  Feeds parsed
+	// from a string or reader have no url, so links that are
+	// still relative after xml:base are left as is.
+	ResolveRelativeLinks bool
+
+	// SynthesizeGUIDs gives items that have neither a guid nor
+	// a link a stable Item.GUID derived from their title and
+	// description, so readers can still tell them apart and
+	// track them across fetches.  Such items keep the same guid
+	// for as long as their title and description don't change.
+	SynthesizeGUIDs bool
+
+	// NormalizeLanguage canonicalizes Feed.Language (and the
+	// sitemap news languages) to BCP-47, e.g. "zh_cn" becomes
+	// "zh-CN".  When false the raw value is kept.
+	NormalizeLanguage bool
+
+	// TrimSpace trims and collapses the whitespace of the feed
+	// and item titles, and trims the descriptions, leaving their
+	// inner whitespace and the item content untouched.  It is
+	// enabled by NewParser.
+	TrimSpace bool
+
+	// MaxItems, when positive, stops parsing a feed once that
+	// many items were read and returns the truncated feed.  The
+	// rest of the input is not read.
+	MaxItems int
+
+	// MinItems, when positive, fails feeds that parse with
+	// fewer items with a TooFewItemsError, so error pages that
+	// happen to be valid empty feeds can be told apart.
+	MinItems int
+
+	// ItemHook, when set, is called for every item after it
+	// was translated to the universal Item.
+	ItemHook func(*Item)
+
+	// FeedHook, when set, is called once with the translated
+	// Feed, after the ItemHook ran for all of its items.  The
+	// sitemap index and site crawls call both hooks once, on the
+	// feed merged from all of their sitemaps.
+	FeedHook func(*Feed)
+
+	// OnFetchComplete, when set, receives the timings of every
+	// feed the Parser's http client fetches, once the response
+	// was read.  Requests are only traced while it is set.
+	OnFetchComplete func(FetchMetrics)
+
+	// TotalDeadline bounds the total time spent crawling the
+	// children of a sitemap index.  When it runs out the items
+	// gathered so far are returned with context.DeadlineExceeded.
+	// Zero means no limit.
+	TotalDeadline time.Duration
+
+	// MaxIndexDepth caps how many levels of nested sitemap
+	// indexes ParseSiteSitemaps expands.  Indexes below it are
+	// dropped.  Zero means 5.
+	MaxIndexDepth int
+
+	// MaxConcurrent caps how many feeds the Parser fetches and
+	// parses from the network at once, across ParseURL, its
+	// variants and sitemap index crawls.  Callers past the limit
+	// wait for a slot.  It is read on the first request and zero
+	// means no limit.
+	MaxConcurrent int
+
+	// HostRequestBudget caps how many requests the Parser makes
+	// to a single host.  Once the budget is spent further requests
+	// to that host fail with a HostBudgetError until Reset is
+	// called.  Zero means no limit.
+	HostRequestBudget int
+
+	// MaxBytes, when positive, caps the size of every response the
+	// Parser fetches: the feeds of ParseURL and its variants and
+	// the sitemaps and robots.txt files of the sitemap crawls.  A
+	// response whose Content-Length is larger is rejected before
+	// its body is read, and one without an honest Content-Length
+	// fails once MaxBytes were read.  Both return
+	// ErrResponseTooLarge.  DetectFeedTypeURL only reads the start
+	// of a body either way.
+	MaxBytes int64
+
+	// Now returns the current time wherever the Parser needs
+	// it, such as the clamping of future sitemap dates and the
+	// crawl state of sitemap indexes.  When nil, time.Now is
+	// used.  Set it to a fixed clock for deterministic tests.
+	Now func() time.Time
+
+	hostMu       sync.Mutex
+	hostRequests map[string]int
+
+	semOnce sync.Once
+	sem     chan struct{}
+
+	// clientMu guards the lazy creation of Client
+	clientMu sync.Mutex
+	// ownClient is the Client the Parser created, if any
+	ownClient *http.Client
+
+	rp *rss.Parser
+	ap *atom.Parser
+	sp *sitemap.Parser
 }
 
 // NewParser creates a universal feed parser.
 func NewParser() *Parser {
 	fp := Parser{
-		rp: &rss.Parser{},
-		ap: &atom.Parser{},
-		sp: &sitemap.Parser{},
+		TrimSpace: true,
+		rp:        &rss.Parser{},
+		ap:        &atom.Parser{},
+		sp:        &sitemap.Parser{},
 	}
 	return &fp
 }
@@ -53,6 +268,16 @@ func NewParser() *Parser {
 // the universal gofeed.Feed.  It takes an
 // io.Reader which should return the xml content.
 func (f *Parser) Parse(feed io.Reader) (*Feed, error) {
+	return f.parse(feed, nil)
+}
+
+// parse is Parse for a feed read from the response described
+// by meta, or from no response at all when meta is nil.
+func (f *Parser) parse(feed io.Reader, meta *ResponseMeta) (*Feed, error) {
+	if f.SkipLeadingJunk {
+		feed = shared.NewLeadingJunkSkipperReader(feed)
+	}
+
 	// Wrap the feed io.Reader in a io.TeeReader
 	// so we can capture all the bytes read by the
 	// DetectFeedType function and construct a new
@@ -60,38 +285,290 @@ func (f *Parser) Parse(feed io.Reader) (*Feed, error) {
 	// attempt to parse the feeds.
 	var buf bytes.Buffer
 	tee := io.TeeReader(feed, &buf)
-	feedType := DetectFeedType(tee)
+	feedType := f.detectFeedType(tee)
 
 	// Glue the read bytes from the detect function
 	// back into a new reader
 	r := io.MultiReader(&buf, feed)
 
+	if feedType == FeedTypeUnknown {
+		return nil, errors.New("Failed to detect feed type")
+	}
+
+	return f.parseReaderWithType(r, feedType, meta)
+}
+
+// ParseReaderWithType parses a feed of a known type into
+// the universal gofeed.Feed.  It skips DetectFeedType and
+// routes the io.Reader straight to the parser and translator
+// for the given FeedType, which is useful when the content
+// is known but detection is unreliable (e.g. piped input).
+func (f *Parser) ParseReaderWithType(feed io.Reader, feedType FeedType) (*Feed, error) {
+	if f.SkipLeadingJunk {
+		feed = shared.NewLeadingJunkSkipperReader(feed)
+	}
+	return f.parseReaderWithType(feed, feedType, nil)
+}
+
+func (f *Parser) parseReaderWithType(feed io.Reader, feedType FeedType, meta *ResponseMeta) (*Feed, error) {
+	if f.SniffEncoding {
+		feed = shared.NewEncodingSnifferReader(feed)
+	}
+	if f.SanitizeInput {
+		feed = shared.NewInputSanitizerReader(feed)
+	}
+	if f.SanitizeInvalidUTF8 {
+		feed = shared.NewUTF8SanitizerReader(feed)
+	}
+	if f.LenientXML {
+		feed = shared.NewAmpersandEscaperReader(feed)
+	}
+
 	switch feedType {
 	case FeedTypeAtom:
-		return f.parseAtomFeed(r)
+		return f.parseAtomFeed(feed, meta)
 	case FeedTypeRSS:
-		return f.parseRSSFeed(r)
+		return f.parseRSSFeed(feed, meta)
 	case FeedTypeSitemap:
-		return f.parseSitemapFeed(r)
+		return f.parseSitemapFeed(feed, meta)
+	case FeedTypeOPML:
+		if translator := f.translator(FeedTypeOPML); translator != nil {
+			return f.parseOPMLFeed(feed, translator, meta)
+		}
 	}
 
-	return nil, errors.New("Failed to detect feed type")
+	return nil, fmt.Errorf("Unsupported feed type: %d", feedType)
 }
 
 // ParseURL fetches the contents of a given url and
 // attempts to parse the response into the universal feed type.
+// Besides http(s) urls, data: urls are decoded inline and
+// file:// urls are read from disk when AllowLocalFiles is set.
 func (f *Parser) ParseURL(feedURL string) (feed *Feed, err error) {
-	client := f.httpClient()
-	resp, err := client.Get(feedURL)
+	return f.parseURL(context.Background(), feedURL)
+}
+
+// ParseAll fetches and parses urls with up to concurrency
+// feeds in flight at a time, one at a time when concurrency is
+// not positive.  The feeds and errors are returned in the order
+// of urls, with a nil error for every feed that parsed.
+// MaxConcurrent and HostRequestBudget apply as for ParseURL.
+// Once ctx is done, urls not yet fetched fail with its error.
+func (f *Parser) ParseAll(ctx context.Context, urls []string, concurrency int) ([]*Feed, []error) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	feeds := make([]*Feed, len(urls))
+	errs := make([]error, len(urls))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency && w < len(urls); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				feeds[i], errs[i] = f.parseURL(ctx, urls[i])
+			}
+		}()
+	}
+	for i := range urls {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return feeds, errs
+}
+
+// RedirectHop is a single redirect followed while fetching a feed.
+type RedirectHop struct {
+	From       string
+	StatusCode int
+	Location   string
+}
+
+// ParseURLVerbose is like ParseURL, but also returns every
+// redirect followed on the way to the final feed url, in order.
+// The hops are returned even when parsing fails.
+func (f *Parser) ParseURLVerbose(feedURL string) (feed *Feed, hops []RedirectHop, err error) {
+	client := *f.httpClient()
+	checkRedirect := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		hop := RedirectHop{From: via[len(via)-1].URL.String()}
+		if req.Response != nil {
+			hop.StatusCode = req.Response.StatusCode
+			hop.Location = req.Response.Header.Get("Location")
+		}
+		hops = append(hops, hop)
+
+		if checkRedirect != nil {
+			return checkRedirect(req, via)
+		}
+		if len(via) >= 10 {
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+
+	if err := f.acquire(context.Background()); err != nil {
+		return nil, hops, err
+	}
+	defer f.release()
+
+	resp, err := f.fetchWithClient(context.Background(), &client, feedURL, nil)
+	if err != nil {
+		return nil, hops, err
+	}
+	defer resp.Body.Close()
+
+	feed, err = f.parseLimited(resp.Body, responseMeta(resp))
+	return feed, hops, err
+}
+
+func (f *Parser) parseURL(ctx context.Context, feedURL string) (feed *Feed, err error) {
+	return f.parseURLSince(ctx, feedURL, time.Time{})
+}
+
+// parseURLSince is parseURL with a conditional request: when
+// since is set, http(s) urls are fetched with If-Modified-Since
+// and an unchanged feed is returned as a 304 HTTPError.
+func (f *Parser) parseURLSince(ctx context.Context, feedURL string, since time.Time) (feed *Feed, err error) {
+	lower := strings.ToLower(feedURL)
+	if strings.HasPrefix(lower, "data:") {
+		return f.parseDataURL(feedURL)
+	}
+	if strings.HasPrefix(lower, "file:") {
+		return f.parseFileURL(feedURL)
+	}
+
+	if err := f.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer f.release()
+
+	body, meta, err := f.openSince(ctx, feedURL, since)
 	if err != nil {
 		return nil, err
 	}
-	if resp.StatusCode < 200 || resp.StatusCode >= 400 {
+	defer func() {
+		ce := body.Close()
+		if ce != nil {
+			err = ce
+		}
+	}()
+
+	if meta == nil {
+		meta = &ResponseMeta{}
+	}
+	if meta.URL == "" {
+		m := *meta
+		m.URL = feedURL
+		meta = &m
+	}
+
+	return f.parseLimited(body, meta)
+}
+
+// parseLimited is parse for a fetched body, held to MaxBytes.
+func (f *Parser) parseLimited(body io.Reader, meta *ResponseMeta) (feed *Feed, err error) {
+	err = f.readLimited(body, meta, func(r io.Reader) (err error) {
+		feed, err = f.parse(r, meta)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return feed, nil
+}
+
+// fetch issues a GET request for the given http(s) url with
+// the Parser's client and headers, bound to ctx.  Responses with an error
+// status are closed and returned as an HTTPError.
+func (f *Parser) fetch(ctx context.Context, feedURL string, since time.Time) (*http.Response, error) {
+	return f.fetchWithClient(ctx, f.httpClient(), feedURL, func(req *http.Request) {
+		if !since.IsZero() {
+			req.Header.Set("If-Modified-Since", since.UTC().Format(http.TimeFormat))
+		}
+	})
+}
+
+// fetchWithClient is fetch with the given client.  prepare, when
+// set, can add request specific headers before it is sent.
+func (f *Parser) fetchWithClient(ctx context.Context, client *http.Client, feedURL string, prepare func(*http.Request)) (*http.Response, error) {
+	req, err := http.NewRequest("GET", feedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return f.do(ctx, client, req, prepare)
+}
+
+// do sends req with the Parser's headers, bound to ctx, and
+// turns error statuses into an HTTPError.
+func (f *Parser) do(ctx context.Context, client *http.Client, req *http.Request, prepare func(*http.Request)) (*http.Response, error) {
+	var trace *fetchTrace
+	if f.OnFetchComplete != nil {
+		trace = newFetchTrace()
+		req = req.WithContext(httptrace.WithClientTrace(ctx, trace.clientTrace()))
+	} else {
+		req = req.WithContext(ctx)
+	}
+	if err := f.countRequest(req.URL.Host); err != nil {
+		return nil, err
+	}
+	if f.AcceptLanguage != "" {
+		req.Header.Set("Accept-Language", f.AcceptLanguage)
+	}
+	if prepare != nil {
+		prepare(req)
+	}
+	acceptGzip(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	if trace != nil {
+		onFetchComplete := f.OnFetchComplete
+		resp.Body = &tracedBody{ReadCloser: resp.Body, report: func() {
+			onFetchComplete(trace.done(resp))
+		}}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 400 || resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
 		return nil, HTTPError{
 			StatusCode: resp.StatusCode,
 			Status:     resp.Status,
 		}
 	}
+	if err := decodeBody(resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ParseURLWithAuth is like ParseURL, but authenticates to the
+// feed's server with HTTP Basic Auth.  It always uses the
+// Parser's http client, as a Fetcher has no way to receive the
+// credentials.  As with any http.Client request, they are not
+// forwarded on redirects to other domains.
+func (f *Parser) ParseURLWithAuth(feedURL, username, password string) (feed *Feed, err error) {
+	if err := f.acquire(context.Background()); err != nil {
+		return nil, err
+	}
+	defer f.release()
+
+	resp, err := f.fetchWithClient(context.Background(), f.httpClient(), feedURL, func(req *http.Request) {
+		req.SetBasicAuth(username, password)
+	})
+	if err != nil {
+		return nil, err
+	}
 	defer func() {
 		ce := resp.Body.Close()
 		if ce != nil {
@@ -99,16 +576,59 @@ func (f *Parser) ParseURL(feedURL string) (feed *Feed, err error) {
 		}
 	}()
 
-	return f.Parse(resp.Body)
+	return f.parseLimited(resp.Body, responseMeta(resp))
 }
 
-//ParseURLWithProxy is add proxy for pasre
+// ParseURLPost is like ParseURL, but fetches the feed with a
+// POST of body, sent with the given Content-Type, for search
+// and other feed endpoints that only answer POST requests.
+// Like ParseURLWithAuth it always uses the Parser's http client.
+func (f *Parser) ParseURLPost(feedURL string, body io.Reader, contentType string) (feed *Feed, err error) {
+	if err := f.acquire(context.Background()); err != nil {
+		return nil, err
+	}
+	defer f.release()
+
+	req, err := http.NewRequest("POST", feedURL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := f.do(context.Background(), f.httpClient(), req, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		ce := resp.Body.Close()
+		if ce != nil {
+			err = ce
+		}
+	}()
+
+	return f.parseLimited(resp.Body, responseMeta(resp))
+}
+
+// ParseURLWithProxy is add proxy for pasre
 func (f *Parser) ParseURLWithProxy(feedURL string, proxyURL string, proxyName string, proxyPasswd string) (feed *Feed, err error) {
 	client := f.httpClientWithProxy(proxyURL)
-	req, _ := http.NewRequest("GET", feedURL, nil)
+	req, err := http.NewRequest("GET", feedURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.acquire(context.Background()); err != nil {
+		return nil, err
+	}
+	defer f.release()
+	if err := f.countRequest(req.URL.Host); err != nil {
+		return nil, err
+	}
 	basePas := base64.StdEncoding.EncodeToString([]byte(proxyName + ":" + proxyPasswd))
 	req.Header.Set("Proxy-Authorization", "Basic "+basePas)
 	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/53.0.2785.89 Safari/537.36")
+	if f.AcceptLanguage != "" {
+		req.Header.Set("Accept-Language", f.AcceptLanguage)
+	}
 	resp, err := client.Do(req)
 
 	if err != nil {
@@ -127,7 +647,7 @@ func (f *Parser) ParseURLWithProxy(feedURL string, proxyURL string, proxyName st
 			err = ce
 		}
 	}()
-	return f.Parse(resp.Body)
+	return f.parseLimited(resp.Body, responseMeta(resp))
 }
 
 // ParseString parses a feed XML string and into the
@@ -136,67 +656,364 @@ func (f *Parser) ParseString(feed string) (*Feed, error) {
 	return f.Parse(strings.NewReader(feed))
 }
 
-func (f *Parser) parseAtomFeed(feed io.Reader) (*Feed, error) {
-	af, err := f.ap.Parse(feed)
+// ParseFile opens the file at the given path and
+// parses its contents into the universal feed type.
+func (f *Parser) ParseFile(path string) (feed *Feed, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		ce := file.Close()
+		if ce != nil && err == nil {
+			err = ce
+		}
+	}()
+
+	return f.Parse(file)
+}
+
+func (f *Parser) parseFileURL(fileURL string) (*Feed, error) {
+	if !f.AllowLocalFiles {
+		return nil, errors.New("file urls are not allowed")
+	}
+
+	u, err := url.Parse(fileURL)
 	if err != nil {
 		return nil, err
 	}
-	return f.atomTrans().Translate(af)
+	if u.Host != "" && u.Host != "localhost" {
+		return nil, fmt.Errorf("file url host %s is not local", u.Host)
+	}
+	return f.ParseFile(u.Path)
 }
 
-func (f *Parser) parseRSSFeed(feed io.Reader) (*Feed, error) {
-	rf, err := f.rp.Parse(feed)
+func (f *Parser) parseDataURL(dataURL string) (*Feed, error) {
+	comma := strings.Index(dataURL, ",")
+	if comma < 0 {
+		return nil, errors.New("data url is missing its data")
+	}
+	meta, data := dataURL[len("data:"):comma], dataURL[comma+1:]
+
+	if strings.HasSuffix(strings.ToLower(meta), ";base64") {
+		content, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, err
+		}
+		return f.Parse(bytes.NewReader(content))
+	}
+
+	content, err := url.PathUnescape(data)
 	if err != nil {
 		return nil, err
 	}
+	return f.ParseString(content)
+}
+
+// ParseAny parses a source that is either an http(s) url,
+// a path to a local file or raw feed content.  The kind of
+// source is decided by the Parser's SourceDetector, which
+// defaults to DetectSourceType, and dispatched to ParseURL,
+// ParseFile or ParseString respectively.
+func (f *Parser) ParseAny(source string) (*Feed, error) {
+	detect := f.SourceDetector
+	if detect == nil {
+		detect = DetectSourceType
+	}
+
+	switch detect(source) {
+	case SourceTypeURL:
+		return f.ParseURL(strings.TrimSpace(source))
+	case SourceTypeFile:
+		return f.ParseFile(strings.TrimSpace(source))
+	}
+	return f.ParseString(source)
+}
+
+// Reset clears the per-host request counters used to
+// enforce HostRequestBudget.
+func (f *Parser) Reset() {
+	f.hostMu.Lock()
+	defer f.hostMu.Unlock()
+	f.hostRequests = nil
+}
+
+// Close closes the idle connections of the http client the
+// Parser created for itself and clears it, so a new one is
+// created on the next request.  A Client set by the caller is
+// left alone, along with its transport.  Calling Close is
+// optional and it is safe to call it multiple times.
+func (f *Parser) Close() {
+	f.clientMu.Lock()
+	defer f.clientMu.Unlock()
+	if f.ownClient == nil {
+		return
+	}
 
-	return f.rssTrans().Translate(rf)
+	type idleCloser interface {
+		CloseIdleConnections()
+	}
+	if t, ok := f.ownClient.Transport.(idleCloser); ok {
+		t.CloseIdleConnections()
+	}
+	if f.Client == f.ownClient {
+		f.Client = nil
+	}
+	f.ownClient = nil
 }
 
-func (f *Parser) parseSitemapFeed(feed io.Reader) (*Feed, error) {
-	sf, err := f.sp.Parse(feed)
+// acquire waits for one of the MaxConcurrent slots, giving
+// up when ctx is done.  Every successful acquire must be
+// paired with a release.
+func (f *Parser) acquire(ctx context.Context) error {
+	f.semOnce.Do(func() {
+		if f.MaxConcurrent > 0 {
+			f.sem = make(chan struct{}, f.MaxConcurrent)
+		}
+	})
+	if f.sem == nil {
+		return nil
+	}
+
+	select {
+	case f.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *Parser) release() {
+	if f.sem != nil {
+		<-f.sem
+	}
+}
+
+func (f *Parser) countRequest(host string) error {
+	if f.HostRequestBudget <= 0 {
+		return nil
+	}
+
+	host = strings.ToLower(host)
+	f.hostMu.Lock()
+	defer f.hostMu.Unlock()
+	if f.hostRequests == nil {
+		f.hostRequests = map[string]int{}
+	}
+	if f.hostRequests[host] >= f.HostRequestBudget {
+		return HostBudgetError{Host: host, Budget: f.HostRequestBudget}
+	}
+	f.hostRequests[host]++
+	return nil
+}
+
+func (f *Parser) parseAtomFeed(feed io.Reader, meta *ResponseMeta) (*Feed, error) {
+	ap := atom.Parser{}
+	if f.ap != nil {
+		ap = *f.ap
+	}
+	ap.CharsetReader = f.CharsetReader
+	ap.MaxItems = f.MaxItems
+	ap.ResolveRelativeLinks = f.ResolveRelativeLinks
+	af, err := ap.Parse(feed)
+	if err != nil {
+		return nil, err
+	}
+	return f.translate(f.translator(FeedTypeAtom), af, meta.url())
+}
+
+func (f *Parser) parseRSSFeed(feed io.Reader, meta *ResponseMeta) (*Feed, error) {
+	rp := rss.Parser{}
+	if f.rp != nil {
+		rp = *f.rp
+	}
+	rp.CharsetReader = f.CharsetReader
+	rp.MaxItems = f.MaxItems
+	rp.ResolveRelativeLinks = f.ResolveRelativeLinks
+	rf, err := rp.Parse(feed)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.translate(f.translator(FeedTypeRSS), rf, meta.url())
+}
+
+func (f *Parser) parseSitemapFeed(feed io.Reader, meta *ResponseMeta) (*Feed, error) {
+	sf, err := f.sitemapParser().Parse(feed)
 	if err != nil {
 		return nil, err
 	}
+	if meta != nil && sf.LastModified == nil {
+		if t, err := http.ParseTime(meta.Header.Get("Last-Modified")); err == nil {
+			sf.LastModified = &t
+		}
+	}
 
-	return f.sitemapTrans().Translate(sf)
+	return f.translate(f.translator(FeedTypeSitemap), sf, meta.url())
 }
 
-func (f *Parser) atomTrans() Translator {
-	if f.AtomTranslator != nil {
-		return f.AtomTranslator
+func (f *Parser) parseOPMLFeed(feed io.Reader, translator Translator, meta *ResponseMeta) (*Feed, error) {
+	op := &opml.Parser{CharsetReader: f.CharsetReader}
+	outlines, err := op.Parse(feed)
+	if err != nil {
+		return nil, err
 	}
-	f.AtomTranslator = &DefaultAtomTranslator{}
-	return f.AtomTranslator
+
+	return f.translate(translator, outlines, meta.url())
 }
 
-func (f *Parser) rssTrans() Translator {
-	if f.RSSTranslator != nil {
-		return f.RSSTranslator
+func (f *Parser) sitemapParser() *sitemap.Parser {
+	sp := sitemap.Parser{}
+	if f.sp != nil {
+		sp = *f.sp
 	}
-	f.RSSTranslator = &DefaultRSSTranslator{}
-	return f.RSSTranslator
+	sp.CharsetReader = f.CharsetReader
+	sp.NormalizeLanguage = f.NormalizeLanguage
+	sp.MaxItems = f.MaxItems
+	sp.Now = f.Now
+	return &sp
 }
 
-func (f *Parser) sitemapTrans() Translator {
-	if f.SitemapTranslator != nil {
-		return f.SitemapTranslator
+func (f *Parser) now() time.Time {
+	if f.Now != nil {
+		return f.Now()
 	}
-	f.SitemapTranslator = &DefaultSitemapTranslator{}
-	return f.SitemapTranslator
+	return time.Now()
+}
+
+// translate translates feed to a universal Feed.  base, when
+// known, is the url of the feed its relative links resolve to.
+func (f *Parser) translate(translator Translator, feed interface{}, base string) (*Feed, error) {
+	result, err := translator.Translate(feed)
+	if err != nil {
+		return nil, err
+	}
+
+	if result == nil {
+		return result, nil
+	}
+
+	if f.NormalizeLanguage {
+		result.Language = shared.NormalizeLanguage(result.Language)
+		for _, item := range result.Items {
+			item.Language = shared.NormalizeLanguage(item.Language)
+		}
+	}
+	if f.TrimSpace {
+		// Descriptions may be html, whose whitespace can matter
+		// (e.g. in <pre> blocks), so only their ends are trimmed
+		result.Title = shared.CollapseWhitespace(result.Title)
+		result.Description = strings.TrimSpace(result.Description)
+		for _, item := range result.Items {
+			item.Title = shared.CollapseWhitespace(item.Title)
+			item.Description = strings.TrimSpace(item.Description)
+		}
+	}
+
+	if f.ResolveRelativeLinks && base != "" {
+		if u, err := url.Parse(base); err == nil {
+			for _, item := range result.Items {
+				item.Link = shared.ResolveURL(u, item.Link)
+			}
+		}
+	}
+
+	if f.SynthesizeGUIDs {
+		for _, item := range result.Items {
+			if item.GUID == "" && item.Link == "" {
+				item.GUID = syntheticGUID(item)
+			}
+		}
+	}
+
+	if f.ItemHook != nil {
+		for _, item := range result.Items {
+			f.ItemHook(item)
+		}
+	}
+	if f.FeedHook != nil {
+		f.FeedHook(result)
+	}
+
+	if f.MinItems > 0 && len(result.Items) < f.MinItems {
+		return nil, TooFewItemsError{Items: len(result.Items), MinItems: f.MinItems}
+	}
+	return result, nil
+}
+
+// syntheticGUID derives a guid from the title and description
+// of item, for items that have no identifier of their own.
+func syntheticGUID(item *Item) string {
+	h := sha1.New()
+	io.WriteString(h, item.Title)
+	h.Write([]byte{0})
+	io.WriteString(h, item.Description)
+	return "urn:sha1:" + hex.EncodeToString(h.Sum(nil))
+}
+
+func (f *Parser) detectFeedType(feed io.Reader) FeedType {
+	if f.CharsetReader == nil {
+		return DetectFeedType(feed)
+	}
+	return detectFeedType(feed, f.CharsetReader)
+}
+
+// translator returns the Translator registered for feedType,
+// falling back to the per type fields and then the defaults.
+// There is no default for types without a built-in translation.
+func (f *Parser) translator(feedType FeedType) Translator {
+	if t := f.Translators[feedType]; t != nil {
+		return t
+	}
+
+	switch feedType {
+	case FeedTypeAtom:
+		if f.AtomTranslator != nil {
+			return f.AtomTranslator
+		}
+		return &DefaultAtomTranslator{}
+	case FeedTypeRSS:
+		if f.RSSTranslator != nil {
+			return f.RSSTranslator
+		}
+		return &DefaultRSSTranslator{}
+	case FeedTypeSitemap:
+		if f.SitemapTranslator != nil {
+			return f.SitemapTranslator
+		}
+		return &DefaultSitemapTranslator{}
+	}
+	return nil
 }
 
 func (f *Parser) httpClient() *http.Client {
+	f.clientMu.Lock()
+	defer f.clientMu.Unlock()
 	if f.Client != nil {
 		return f.Client
 	}
 	timeout := time.Duration(30 * time.Second)
 
 	f.Client = &http.Client{Timeout: timeout}
+	if f.DialContext != nil || f.TLSConfig != nil {
+		// Start from the default transport to keep its timeouts,
+		// connection pooling and HTTP/2 support
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		if f.DialContext != nil {
+			transport.DialContext = f.DialContext
+		}
+		if f.TLSConfig != nil {
+			transport.TLSClientConfig = f.TLSConfig
+		}
+		f.Client.Transport = transport
+	}
+	f.ownClient = f.Client
 	return f.Client
 }
 
 func (f *Parser) httpClientWithProxy(uRLProxy string) *http.Client {
+	f.clientMu.Lock()
+	defer f.clientMu.Unlock()
 	if f.Client != nil {
 		return f.Client
 	}
@@ -208,9 +1025,12 @@ func (f *Parser) httpClientWithProxy(uRLProxy string) *http.Client {
 		Transport: &http.Transport{
 			TLSHandshakeTimeout:   15 * time.Second,
 			ExpectContinueTimeout: 10 * time.Second,
-			Proxy: http.ProxyURL(urlProxys),
+			Proxy:                 http.ProxyURL(urlProxys),
+			DialContext:           f.DialContext,
+			TLSClientConfig:       f.TLSConfig,
 		},
 		Timeout: timeout,
 	}
+	f.ownClient = f.Client
 	return f.Client
 }
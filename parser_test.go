@@ -2,16 +2,31 @@ package gofeed_test
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"testing/iotest"
+	"time"
+	"unicode/utf8"
 
 	"github.com/shuyaoyimei/gofeed"
+	"github.com/shuyaoyimei/gofeed/opml"
+	"github.com/shuyaoyimei/gofeed/sitemap"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -26,6 +41,8 @@ func TestParser_Parse(t *testing.T) {
 		{"atom10_feed.xml", "atom", "Feed Title", false},
 		{"rss_feed.xml", "rss", "Feed Title", false},
 		{"rdf_feed.xml", "rss", "Feed Title", false},
+		{"rss_feed_trailing_garbage.xml", "rss", "Feed Title", false},
+		{"atom10_feed_trailing_garbage.xml", "atom", "Feed Title", false},
 		{"unknown_feed.xml", "", "", true},
 		{"empty_feed.xml", "", "", true},
 	}
@@ -91,6 +108,64 @@ func TestParser_ParseString(t *testing.T) {
 	}
 }
 
+func TestParser_ParseZeroValue(t *testing.T) {
+	// A Parser that wasn't made by NewParser still parses
+	fp := &gofeed.Parser{}
+	for _, file := range []string{"atom10_feed.xml", "rss_feed.xml"} {
+		f, _ := ioutil.ReadFile("testdata/parser/universal/" + file)
+		feed, err := fp.Parse(bytes.NewReader(f))
+		assert.Nil(t, err, file)
+		if assert.NotNil(t, feed, file) {
+			assert.Equal(t, "Feed Title", feed.Title, file)
+		}
+	}
+
+	feed, err := fp.ParseString(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>http://www.example.com/</loc></url></urlset>`)
+	assert.Nil(t, err)
+	if assert.NotNil(t, feed) {
+		assert.Len(t, feed.Items, 1)
+	}
+}
+
+func TestParser_ParseReaderWithType(t *testing.T) {
+	var feedTests = []struct {
+		file        string
+		forcedType  gofeed.FeedType
+		feedVersion string
+		hasError    bool
+	}{
+		{"universal/atom03_feed.xml", gofeed.FeedTypeAtom, "0.3", false},
+		{"universal/atom10_feed.xml", gofeed.FeedTypeAtom, "1.0", false},
+		{"universal/rss_feed.xml", gofeed.FeedTypeRSS, "2.0", false},
+		{"universal/rdf_feed.xml", gofeed.FeedTypeRSS, "1.0", false},
+		{"sitemap/sitemao01_news.xml", gofeed.FeedTypeSitemap, "0.9", false},
+		{"universal/rss_feed.xml", gofeed.FeedTypeAtom, "", true},
+		{"universal/atom10_feed.xml", gofeed.FeedTypeRSS, "", true},
+		{"universal/rss_feed.xml", gofeed.FeedTypeUnknown, "", true},
+	}
+
+	for _, test := range feedTests {
+		fmt.Printf("Testing %s... ", test.file)
+
+		// Get feed content
+		path := fmt.Sprintf("testdata/parser/%s", test.file)
+		f, _ := ioutil.ReadFile(path)
+
+		// Get actual value
+		fp := gofeed.NewParser()
+		feed, err := fp.ParseReaderWithType(bytes.NewReader(f), test.forcedType)
+
+		if test.hasError {
+			assert.NotNil(t, err)
+			assert.Nil(t, feed)
+		} else {
+			assert.NotNil(t, feed)
+			assert.Nil(t, err)
+			assert.Equal(t, test.feedVersion, feed.FeedVersion)
+		}
+	}
+}
+
 func TestParser_ParseURL_Success(t *testing.T) {
 	var feedTests = []struct {
 		file      string
@@ -141,8 +216,1052 @@ func TestParser_ParseURL_Failure(t *testing.T) {
 	assert.Nil(t, feed)
 }
 
+func TestParser_ParseAny(t *testing.T) {
+	path := "testdata/parser/universal/rss_feed.xml"
+	f, _ := ioutil.ReadFile(path)
+
+	// File path
+	fp := gofeed.NewParser()
+	feed, err := fp.ParseAny(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "Feed Title", feed.Title)
+
+	// Raw content
+	feed, err = fp.ParseAny(string(f))
+	assert.Nil(t, err)
+	assert.Equal(t, "Feed Title", feed.Title)
+
+	// URL
+	server, client := mockServerResponse(200, string(f))
+	fp.Client = client
+	feed, err = fp.ParseAny(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, "Feed Title", feed.Title)
+
+	// Overridden detection
+	fp = gofeed.NewParser()
+	fp.SourceDetector = func(source string) gofeed.SourceType {
+		return gofeed.SourceTypeString
+	}
+	feed, err = fp.ParseAny(path)
+	assert.NotNil(t, err)
+	assert.Nil(t, feed)
+}
+
+func TestParser_ParseURL_DialContext(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed.xml")
+	server, _ := mockServerResponse(200, string(f))
+	defer server.Close()
+
+	var dialed []string
+	fp := gofeed.NewParser()
+	fp.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = append(dialed, addr)
+		var d net.Dialer
+		return d.DialContext(ctx, network, addr)
+	}
+	feed, err := fp.ParseURL(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, "Feed Title", feed.Title)
+	assert.Equal(t, []string{strings.TrimPrefix(server.URL, "http://")}, dialed)
+
+	// The rest of the default transport settings are kept
+	if transport, ok := fp.Client.Transport.(*http.Transport); assert.True(t, ok) {
+		defaults := http.DefaultTransport.(*http.Transport)
+		assert.Equal(t, defaults.TLSHandshakeTimeout, transport.TLSHandshakeTimeout)
+		assert.Equal(t, defaults.IdleConnTimeout, transport.IdleConnTimeout)
+		assert.Equal(t, defaults.MaxIdleConns, transport.MaxIdleConns)
+		assert.True(t, transport.ForceAttemptHTTP2)
+	}
+
+	// Refuse every connection
+	fp = gofeed.NewParser()
+	fp.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, errors.New("address not allowed")
+	}
+	feed, err = fp.ParseURL(server.URL)
+	assert.NotNil(t, err)
+	assert.Nil(t, feed)
+}
+
+func TestParser_Parse_CharsetReader(t *testing.T) {
+	feedData := `<?xml version="1.0" encoding="x-tilde"?>
+<rss version="2.0"><channel><title>Caf~e</title></channel></rss>`
+
+	// The default charset handling doesn't know the label
+	fp := gofeed.NewParser()
+	feed, err := fp.ParseString(feedData)
+	assert.NotNil(t, err)
+	assert.Nil(t, feed)
+
+	var labels []string
+	fp = gofeed.NewParser()
+	fp.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		labels = append(labels, charset)
+		if charset != "x-tilde" {
+			return nil, fmt.Errorf("unsupported charset: %s", charset)
+		}
+		b, err := ioutil.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		return strings.NewReader(strings.Replace(string(b), "~e", "\u00e9", -1)), nil
+	}
+	feed, err = fp.ParseString(feedData)
+	assert.Nil(t, err)
+	assert.Equal(t, "Caf\u00e9", feed.Title)
+	assert.Equal(t, []string{"x-tilde", "x-tilde"}, labels)
+}
+
+func TestParser_ParseURL_AcceptLanguage(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed.xml")
+
+	var header string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("Accept-Language")
+		w.Write(f)
+	}))
+	defer server.Close()
+
+	fp := gofeed.NewParser()
+	_, err := fp.ParseURL(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, "", header)
+
+	fp = gofeed.NewParser()
+	fp.AcceptLanguage = "de-CH, de;q=0.9"
+	feed, err := fp.ParseURL(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, "Feed Title", feed.Title)
+	assert.Equal(t, "de-CH, de;q=0.9", header)
+}
+
+func TestParser_ParseURL_ContentEncoding(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed.xml")
+	var gzipped bytes.Buffer
+	gw := gzip.NewWriter(&gzipped)
+	gw.Write(f)
+	gw.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/gzip":
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(gzipped.Bytes())
+		case "/lying":
+			// Labeled as gzip, sent as is
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Write(f)
+		default:
+			w.Write(f)
+		}
+	}))
+	defer server.Close()
+
+	for _, path := range []string{"/gzip", "/lying", "/plain"} {
+		fp := gofeed.NewParser()
+		feed, err := fp.ParseURL(server.URL + path)
+		if assert.Nil(t, err, path) {
+			assert.Equal(t, "Feed Title", feed.Title, path)
+		}
+
+		fp.Fetcher = &gofeed.HTTPFetcher{}
+		feed, err = fp.ParseURL(server.URL + path)
+		if assert.Nil(t, err, path) {
+			assert.Equal(t, "Feed Title", feed.Title, path)
+		}
+	}
+}
+
+func TestParser_ParseURL_SitemapLastModified(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/sitemap/sitemap_alternates.xml")
+
+	lastModified := "Wed, 01 Feb 2017 10:00:00 GMT"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if lastModified != "" {
+			w.Header().Set("Last-Modified", lastModified)
+		}
+		w.Write(f)
+	}))
+	defer server.Close()
+
+	var sitemapFeed *sitemap.Feed
+	fp := gofeed.NewParser()
+	fp.SitemapTranslator = translatorFunc(func(feed interface{}) (*gofeed.Feed, error) {
+		sitemapFeed = feed.(*sitemap.Feed)
+		return (&gofeed.DefaultSitemapTranslator{}).Translate(feed)
+	})
+
+	feed, err := fp.ParseURL(server.URL)
+	assert.Nil(t, err)
+	expected := time.Date(2017, 2, 1, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, &expected, sitemapFeed.LastModified)
+	assert.Equal(t, &expected, feed.UpdatedParsed)
+
+	lastModified = ""
+	feed, err = fp.ParseURL(server.URL)
+	assert.Nil(t, err)
+	assert.Nil(t, sitemapFeed.LastModified)
+	assert.Nil(t, feed.UpdatedParsed)
+
+	// Strings have no response to take the header from
+	_, err = fp.ParseString(string(f))
+	assert.Nil(t, err)
+	assert.Nil(t, sitemapFeed.LastModified)
+}
+
+func TestParser_ParseURLWithAuth(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed.xml")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "reader" || pass != "s3cret" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="feeds"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write(f)
+	}))
+	defer server.Close()
+
+	fp := gofeed.NewParser()
+	feed, err := fp.ParseURLWithAuth(server.URL, "reader", "s3cret")
+	assert.Nil(t, err)
+	assert.Equal(t, "Feed Title", feed.Title)
+
+	feed, err = fp.ParseURLWithAuth(server.URL, "reader", "wrong")
+	assert.Nil(t, feed)
+	assert.Equal(t, gofeed.HTTPError{StatusCode: 401, Status: "401 Unauthorized"}, err)
+
+	_, err = fp.ParseURL(server.URL)
+	assert.IsType(t, gofeed.HTTPError{}, err)
+}
+
+func TestParser_Parse_ResolveRelativeLinks(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed_relative_links.xml")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(f)
+	}))
+	defer server.Close()
+
+	fp := gofeed.NewParser()
+	fp.ResolveRelativeLinks = true
+	feed, err := fp.ParseURL(server.URL + "/feeds/rss.xml")
+	assert.Nil(t, err)
+	if assert.Len(t, feed.Items, 3) {
+		assert.Equal(t, server.URL+"/feeds/posts/1.html", feed.Items[0].Link)
+		assert.Equal(t, "http://cdn.example.org/archive/2.html", feed.Items[1].Link)
+		assert.Equal(t, "http://other.example.org/3.html", feed.Items[2].Link)
+	}
+
+	// Without a feed url only xml:base applies
+	feed, err = fp.ParseString(string(f))
+	assert.Nil(t, err)
+	if assert.Len(t, feed.Items, 3) {
+		assert.Equal(t, "posts/1.html", feed.Items[0].Link)
+		assert.Equal(t, "http://cdn.example.org/archive/2.html", feed.Items[1].Link)
+	}
+
+	fp.ResolveRelativeLinks = false
+	feed, err = fp.ParseURL(server.URL + "/feeds/rss.xml")
+	assert.Nil(t, err)
+	assert.Equal(t, "posts/1.html", feed.Items[0].Link)
+	assert.Equal(t, "2.html", feed.Items[1].Link)
+
+	f, _ = ioutil.ReadFile("testdata/parser/universal/atom10_feed_relative_links.xml")
+	fp.ResolveRelativeLinks = true
+	feed, err = fp.ParseString(string(f))
+	assert.Nil(t, err)
+	if assert.Len(t, feed.Items, 2) {
+		assert.Equal(t, "http://example.org/blog/posts/1.html", feed.Items[0].Link)
+		assert.Equal(t, "http://example.org/archive/2.html", feed.Items[1].Link)
+	}
+}
+
+func TestParser_ParseURLPost(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed.xml")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		r.ParseForm()
+		if r.Header.Get("Content-Type") != "application/x-www-form-urlencoded" || r.PostForm.Get("q") != "golang" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Write(f)
+	}))
+	defer server.Close()
+
+	fp := gofeed.NewParser()
+	fp.AcceptLanguage = "de"
+	feed, err := fp.ParseURLPost(server.URL, strings.NewReader("q=golang"), "application/x-www-form-urlencoded")
+	assert.Nil(t, err)
+	assert.Equal(t, "Feed Title", feed.Title)
+
+	feed, err = fp.ParseURLPost(server.URL, strings.NewReader("q=other"), "application/x-www-form-urlencoded")
+	assert.Nil(t, feed)
+	assert.Equal(t, gofeed.HTTPError{StatusCode: 400, Status: "400 Bad Request"}, err)
+
+	_, err = fp.ParseURL(server.URL)
+	assert.Equal(t, gofeed.HTTPError{StatusCode: 405, Status: "405 Method Not Allowed"}, err)
+}
+
+func TestParser_ParseURLVerbose(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed.xml")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/moved", http.StatusMovedPermanently)
+		case "/moved":
+			http.Redirect(w, r, "/feed", http.StatusFound)
+		default:
+			w.Write(f)
+		}
+	}))
+	defer server.Close()
+
+	fp := gofeed.NewParser()
+	feed, hops, err := fp.ParseURLVerbose(server.URL + "/old")
+	assert.Nil(t, err)
+	assert.Equal(t, "Feed Title", feed.Title)
+	assert.Equal(t, []gofeed.RedirectHop{
+		{From: server.URL + "/old", StatusCode: 301, Location: "/moved"},
+		{From: server.URL + "/moved", StatusCode: 302, Location: "/feed"},
+	}, hops)
+
+	feed, hops, err = fp.ParseURLVerbose(server.URL + "/feed")
+	assert.Nil(t, err)
+	assert.NotNil(t, feed)
+	assert.Nil(t, hops)
+}
+
+func TestParser_ParseURL_TLSConfig(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed.xml")
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(f)
+	}))
+	defer server.Close()
+
+	// The test server's certificate isn't trusted by default
+	fp := gofeed.NewParser()
+	_, err := fp.ParseURL(server.URL)
+	assert.NotNil(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	fp = gofeed.NewParser()
+	fp.TLSConfig = &tls.Config{RootCAs: pool}
+	feed, err := fp.ParseURL(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, "Feed Title", feed.Title)
+}
+
+func TestParser_ParseURL_OnFetchComplete(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed.xml")
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/feed", http.StatusMovedPermanently)
+			return
+		}
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+		w.Write(f)
+	}))
+	defer server.Close()
+
+	pool := x509.NewCertPool()
+	pool.AddCert(server.Certificate())
+	fp := gofeed.NewParser()
+	fp.TLSConfig = &tls.Config{RootCAs: pool}
+
+	var metrics []gofeed.FetchMetrics
+	fp.OnFetchComplete = func(m gofeed.FetchMetrics) {
+		metrics = append(metrics, m)
+	}
+
+	feed, err := fp.ParseURL(server.URL + "/old")
+	assert.Nil(t, err)
+	assert.Equal(t, "Feed Title", feed.Title)
+	if assert.Len(t, metrics, 1) {
+		m := metrics[0]
+		assert.Equal(t, server.URL+"/feed", m.URL)
+		assert.Equal(t, 200, m.StatusCode)
+		assert.True(t, m.DNS >= 0)
+		assert.True(t, m.Connect > 0)
+		assert.True(t, m.TLS > 0)
+		assert.True(t, m.FirstByte >= 10*time.Millisecond)
+		assert.True(t, m.Total >= m.FirstByte)
+		// The wait for the first byte excludes the connection setup
+		assert.True(t, m.Total >= m.Connect+m.TLS+m.FirstByte)
+	}
+
+	// Error responses are reported too
+	metrics = nil
+	_, err = fp.ParseURL(server.URL + "/missing")
+	assert.IsType(t, gofeed.HTTPError{}, err)
+	if assert.Len(t, metrics, 1) {
+		assert.Equal(t, 404, metrics[0].StatusCode)
+	}
+
+	// Without a callback nothing is traced
+	metrics = nil
+	fp.OnFetchComplete = nil
+	_, err = fp.ParseURL(server.URL + "/feed")
+	assert.Nil(t, err)
+	assert.Empty(t, metrics)
+}
+
+func TestParser_ParseURL_HostRequestBudget(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed.xml")
+	server, client := mockServerResponse(200, string(f))
+	defer server.Close()
+
+	fp := gofeed.NewParser()
+	fp.Client = client
+	fp.HostRequestBudget = 2
+	for i := 0; i < 2; i++ {
+		feed, err := fp.ParseURL(server.URL)
+		assert.Nil(t, err)
+		assert.Equal(t, "Feed Title", feed.Title)
+	}
+
+	feed, err := fp.ParseURL(server.URL)
+	assert.Nil(t, feed)
+	if assert.IsType(t, gofeed.HostBudgetError{}, err) {
+		assert.Equal(t, 2, err.(gofeed.HostBudgetError).Budget)
+	}
+
+	fp.Reset()
+	feed, err = fp.ParseURL(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, "Feed Title", feed.Title)
+}
+
+func TestParser_ParseURL_MaxConcurrent(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed.xml")
+
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+		w.Write(f)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	fp := gofeed.NewParser()
+	fp.MaxConcurrent = 2
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			feed, err := fp.ParseURL(server.URL)
+			assert.Nil(t, err)
+			assert.Equal(t, "Feed Title", feed.Title)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 2, maxInFlight)
+}
+
+func TestParser_ParseAll(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed.xml")
+
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxInFlight {
+			maxInFlight = inFlight
+		}
+		mu.Unlock()
+
+		time.Sleep(20 * time.Millisecond)
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+		} else {
+			w.Write(f)
+		}
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	urls := []string{}
+	for i := 0; i < 6; i++ {
+		urls = append(urls, fmt.Sprintf("%s/feed%d", server.URL, i))
+	}
+	urls[3] = server.URL + "/missing"
+
+	fp := gofeed.NewParser()
+	feeds, errs := fp.ParseAll(context.Background(), urls, 3)
+	assert.Len(t, feeds, 6)
+	assert.Len(t, errs, 6)
+	for i := range urls {
+		if i == 3 {
+			assert.Nil(t, feeds[i])
+			assert.Equal(t, gofeed.HTTPError{StatusCode: 404, Status: "404 Not Found"}, errs[i])
+			continue
+		}
+		assert.Nil(t, errs[i])
+		assert.Equal(t, "Feed Title", feeds[i].Title)
+	}
+	assert.Equal(t, 3, maxInFlight)
+
+	// A budget of 2 requests per host fails the rest
+	fp = gofeed.NewParser()
+	fp.HostRequestBudget = 2
+	_, errs = fp.ParseAll(context.Background(), urls, 1)
+	assert.Nil(t, errs[0])
+	assert.Nil(t, errs[1])
+	assert.IsType(t, gofeed.HostBudgetError{}, errs[2])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	feeds, errs = fp.ParseAll(ctx, urls, 2)
+	for i := range urls {
+		assert.Nil(t, feeds[i])
+		assert.Equal(t, context.Canceled, errs[i])
+	}
+}
+
+func TestParser_ParseURL_MaxBytes(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed.xml")
+	big := append(append([]byte{}, f...), bytes.Repeat([]byte("\n"), 1024)...)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := f
+		if r.URL.Path == "/big" {
+			body = big
+		}
+		if r.URL.Query().Get("chunked") != "" {
+			// Flushing before writing drops the Content-Length
+			w.(http.Flusher).Flush()
+		}
+		w.Write(body)
+	}))
+	defer server.Close()
+
+	fp := gofeed.NewParser()
+	fp.MaxBytes = int64(len(f))
+
+	feed, err := fp.ParseURL(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, "Feed Title", feed.Title)
+
+	feed, err = fp.ParseURL(server.URL + "?chunked=1")
+	assert.Nil(t, err)
+	assert.Equal(t, "Feed Title", feed.Title)
+
+	feed, err = fp.ParseURL(server.URL + "/big")
+	assert.Nil(t, feed)
+	assert.Equal(t, gofeed.ErrResponseTooLarge, err)
+
+	feed, err = fp.ParseURL(server.URL + "/big?chunked=1")
+	assert.Nil(t, feed)
+	assert.Equal(t, gofeed.ErrResponseTooLarge, err)
+
+	// The other fetching entry points are held to it too
+	feed, err = fp.ParseURLWithAuth(server.URL+"/big?chunked=1", "user", "secret")
+	assert.Nil(t, feed)
+	assert.Equal(t, gofeed.ErrResponseTooLarge, err)
+
+	feed, err = fp.ParseURLPost(server.URL+"/big", strings.NewReader("q=feed"), "application/x-www-form-urlencoded")
+	assert.Nil(t, feed)
+	assert.Equal(t, gofeed.ErrResponseTooLarge, err)
+
+	fp.MaxBytes = 0
+	_, err = fp.ParseURL(server.URL + "/big?chunked=1")
+	assert.Nil(t, err)
+}
+
+func TestParser_Parse_MaxItems(t *testing.T) {
+	var rssItems, atomEntries string
+	for i := 1; i <= 1000; i++ {
+		rssItems += fmt.Sprintf("<item><title>Item %d</title></item>", i)
+		atomEntries += fmt.Sprintf("<entry><title>Entry %d</title></entry>", i)
+	}
+	feeds := []string{
+		`<rss version="2.0"><channel><title>Feed Title</title>` + rssItems + `</channel></rss>`,
+		`<feed xmlns="http://www.w3.org/2005/Atom"><title>Feed Title</title>` + atomEntries + `</feed>`,
+	}
+
+	for _, feed := range feeds {
+		fp := gofeed.NewParser()
+		fp.MaxItems = 10
+		result, err := fp.ParseString(feed)
+		assert.Nil(t, err)
+		assert.Equal(t, "Feed Title", result.Title)
+		if assert.Len(t, result.Items, 10) {
+			assert.True(t, strings.HasSuffix(result.Items[9].Title, " 10"))
+		}
+	}
+
+	// A repeated channel stops at the limit too, before the
+	// truncated rest of the document
+	fp := gofeed.NewParser()
+	fp.MaxItems = 10
+	result, err := fp.ParseString(`<rss version="2.0"><channel><title>Feed Title</title>` +
+		rssItems[:strings.Index(rssItems, "<item><title>Item 4<")] + `</channel><channel>` + rssItems[:strings.Index(rssItems, "<item><title>Item 9<")])
+	assert.Nil(t, err)
+	if assert.Len(t, result.Items, 10) {
+		assert.Equal(t, "Item 3", result.Items[2].Title)
+		assert.Equal(t, "Item 7", result.Items[9].Title)
+	}
+}
+
+func TestParser_Parse_MinItems(t *testing.T) {
+	feed := func(n int) string {
+		var items string
+		for i := 1; i <= n; i++ {
+			items += fmt.Sprintf("<item><title>Item %d</title></item>", i)
+		}
+		return `<rss version="2.0"><channel><title>Feed Title</title>` + items + `</channel></rss>`
+	}
+
+	tests := []struct {
+		items    int
+		minItems int
+		err      error
+	}{
+		{0, 0, nil},
+		{3, 0, nil},
+		{0, 1, gofeed.TooFewItemsError{Items: 0, MinItems: 1}},
+		{1, 1, nil},
+		{2, 3, gofeed.TooFewItemsError{Items: 2, MinItems: 3}},
+		{3, 3, nil},
+		{5, 3, nil},
+	}
+
+	for _, test := range tests {
+		fp := gofeed.NewParser()
+		fp.MinItems = test.minItems
+		result, err := fp.ParseString(feed(test.items))
+		assert.Equal(t, test.err, err, "%d items with MinItems %d", test.items, test.minItems)
+		if test.err == nil {
+			assert.Len(t, result.Items, test.items)
+		} else {
+			assert.Nil(t, result)
+		}
+	}
+}
+
+func TestParser_Parse_AstralReferences(t *testing.T) {
+	for _, file := range []string{"rss_feed_astral_references.xml", "atom10_feed_astral_references.xml"} {
+		f, _ := ioutil.ReadFile("testdata/parser/universal/" + file)
+
+		fp := gofeed.NewParser()
+		feed, err := fp.Parse(bytes.NewReader(f))
+		assert.Nil(t, err, file)
+		assert.Equal(t, "Smile \U0001F600", feed.Title, file)
+		if assert.Len(t, feed.Items, 1, file) {
+			assert.Equal(t, "Party \U0001F389 \U0001F600", feed.Items[0].Title, file)
+			assert.Equal(t, "<p>Hi \U0001F44B</p>", feed.Items[0].Description, file)
+			assert.True(t, utf8.ValidString(feed.Items[0].Description), file)
+		}
+	}
+}
+
+func TestParser_Parse_SanitizeInvalidUTF8(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed_invalid_utf8.xml")
+
+	fp := gofeed.NewParser()
+	_, err := fp.Parse(bytes.NewReader(f))
+	assert.NotNil(t, err)
+
+	fp.SanitizeInvalidUTF8 = true
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Equal(t, "Caf\uFFFD Feed", feed.Title)
+	if assert.Len(t, feed.Items, 1) {
+		assert.Equal(t, "Na\uFFFDve été", feed.Items[0].Title)
+	}
+}
+
+func TestParser_Parse_SniffEncoding(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed_mismatched_encoding.xml")
+
+	fp := gofeed.NewParser()
+	_, err := fp.Parse(bytes.NewReader(f))
+	assert.NotNil(t, err)
+
+	fp.SniffEncoding = true
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Equal(t, "Café Feed", feed.Title)
+	if assert.Len(t, feed.Items, 1) {
+		assert.Equal(t, "Naïve été – the chef’s picks", feed.Items[0].Title)
+	}
+
+	// Feeds that are valid utf-8 are parsed as before
+	f, _ = ioutil.ReadFile("testdata/parser/universal/rss_feed.xml")
+	expected, err := gofeed.NewParser().Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	feed, err = fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Equal(t, expected, feed)
+}
+
+func TestParser_Parse_SynthesizeGUIDs(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed_linkless_items.xml")
+
+	fp := gofeed.NewParser()
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	if assert.Len(t, feed.Items, 5) {
+		for i := 0; i < 3; i++ {
+			assert.Empty(t, feed.Items[i].GUID)
+		}
+	}
+
+	fp.SynthesizeGUIDs = true
+	feed, err = fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	again, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	if assert.Len(t, feed.Items, 5) {
+		seen := map[string]bool{}
+		for i := 0; i < 3; i++ {
+			guid := feed.Items[i].GUID
+			assert.True(t, strings.HasPrefix(guid, "urn:sha1:"), guid)
+			assert.False(t, seen[guid], "item %d has a duplicate guid", i)
+			seen[guid] = true
+			assert.Equal(t, guid, again.Items[i].GUID, "item %d guid is not stable", i)
+		}
+
+		// Items with an identifier of their own are left alone
+		assert.Empty(t, feed.Items[3].GUID)
+		assert.Equal(t, "announcement-42", feed.Items[4].GUID)
+	}
+}
+
+func TestParser_Parse_SanitizeInput(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed_null_bytes.xml")
+
+	fp := gofeed.NewParser()
+	_, err := fp.Parse(bytes.NewReader(f))
+	assert.NotNil(t, err)
+
+	fp.SanitizeInput = true
+	fp.TrimSpace = false
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Equal(t, "Feed Title", feed.Title)
+	if assert.Len(t, feed.Items, 1) {
+		assert.Equal(t, "Item Title", feed.Items[0].Title)
+		assert.Equal(t, "Line one\nLine two\nLine three", feed.Items[0].Description)
+	}
+}
+
+func TestParser_Parse_LenientXML(t *testing.T) {
+	tests := []struct {
+		file string
+		link string
+	}{
+		{"rss_feed_bare_ampersands.xml", "http://example.org/item?id=1&ref=rss"},
+		{"atom10_feed_bare_ampersands.xml", "http://example.org/item?id=1&ref=atom"},
+	}
+
+	for _, test := range tests {
+		f, _ := ioutil.ReadFile("testdata/parser/universal/" + test.file)
+
+		fp := gofeed.NewParser()
+		_, err := fp.Parse(bytes.NewReader(f))
+		assert.NotNil(t, err, test.file)
+
+		fp.LenientXML = true
+		feed, err := fp.Parse(bytes.NewReader(f))
+		assert.Nil(t, err, test.file)
+		assert.Equal(t, "Fish & Chips", feed.Title)
+		if assert.Len(t, feed.Items, 1) {
+			assert.Equal(t, "Salt & Vinegar & More", feed.Items[0].Title)
+			assert.Equal(t, test.link, feed.Items[0].Link)
+		}
+	}
+}
+
+func TestParser_Parse_SkipLeadingJunk(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed_leading_headers.xml")
+
+	fp := gofeed.NewParser()
+	_, err := fp.Parse(bytes.NewReader(f))
+	assert.NotNil(t, err)
+
+	fp.SkipLeadingJunk = true
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Equal(t, "rss", feed.FeedType)
+	assert.Equal(t, "Feed Title", feed.Title)
+	if assert.Len(t, feed.Items, 1) {
+		assert.Equal(t, "Item Title", feed.Items[0].Title)
+	}
+
+	feed, err = fp.ParseReaderWithType(bytes.NewReader(f), gofeed.FeedTypeRSS)
+	assert.Nil(t, err)
+	assert.Equal(t, "Feed Title", feed.Title)
+}
+
+func TestParser_ParseURL_Chunked(t *testing.T) {
+	files := []string{
+		"testdata/parser/universal/rss_feed.xml",
+		"testdata/parser/universal/atom10_feed.xml",
+	}
+
+	for _, file := range files {
+		f, _ := ioutil.ReadFile(file)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Flushing every few bytes makes the server use a
+			// chunked response without a Content-Length
+			for i := 0; i < len(f); i += 7 {
+				end := i + 7
+				if end > len(f) {
+					end = len(f)
+				}
+				w.Write(f[i:end])
+				w.(http.Flusher).Flush()
+			}
+		}))
+
+		fp := gofeed.NewParser()
+		feed, err := fp.ParseURL(server.URL)
+		assert.Nil(t, err, file)
+		if assert.NotNil(t, feed, file) {
+			assert.Equal(t, "Feed Title", feed.Title, file)
+		}
+		server.Close()
+
+		// Readers returning a byte at a time, or their data
+		// together with io.EOF, must parse the same
+		for _, r := range []io.Reader{
+			iotest.OneByteReader(bytes.NewReader(f)),
+			iotest.DataErrReader(bytes.NewReader(f)),
+		} {
+			feed, err = fp.Parse(r)
+			assert.Nil(t, err, file)
+			if assert.NotNil(t, feed, file) {
+				assert.Equal(t, "Feed Title", feed.Title, file)
+			}
+		}
+	}
+}
+
+func TestParser_ParseURL_DataURL(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed.xml")
+
+	fp := gofeed.NewParser()
+	feed, err := fp.ParseURL("data:application/rss+xml;base64," + base64.StdEncoding.EncodeToString(f))
+	assert.Nil(t, err)
+	assert.Equal(t, "Feed Title", feed.Title)
+
+	feed, err = fp.ParseURL("data:," + url.PathEscape(string(f)))
+	assert.Nil(t, err)
+	assert.Equal(t, "Feed Title", feed.Title)
+
+	feed, err = fp.ParseURL("data:;base64,not base64!")
+	assert.NotNil(t, err)
+	assert.Nil(t, feed)
+}
+
+func TestParser_ParseURL_FileURL(t *testing.T) {
+	path, _ := filepath.Abs("testdata/parser/universal/rss_feed.xml")
+	fileURL := (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+
+	// Local files are refused by default
+	fp := gofeed.NewParser()
+	feed, err := fp.ParseURL(fileURL)
+	assert.NotNil(t, err)
+	assert.Nil(t, feed)
+
+	fp.AllowLocalFiles = true
+	feed, err = fp.ParseURL(fileURL)
+	assert.Nil(t, err)
+	assert.Equal(t, "Feed Title", feed.Title)
+
+	feed, err = fp.ParseURL("file://example.com/etc/feed.xml")
+	assert.NotNil(t, err)
+	assert.Nil(t, feed)
+}
+
+func TestParser_Parse_NormalizeLanguage(t *testing.T) {
+	var langTests = []struct {
+		feed     string
+		raw      string
+		expected string
+	}{
+		{`<rss version="2.0"><channel><language>EN_us</language></channel></rss>`, "EN_us", "en-US"},
+		{`<feed xmlns="http://www.w3.org/2005/Atom" xml:lang="zh_CN"></feed>`, "zh_CN", "zh-CN"},
+		{`<rss version="2.0"><channel><language>english</language></channel></rss>`, "english", "english"},
+		{`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:news="http://www.google.com/schemas/sitemap-news/0.9">
+<url><loc>http://example.com/</loc><news:news><news:publication><news:name>Example</news:name><news:language>PT_br</news:language></news:publication></news:news></url>
+</urlset>`, "PT_br", "pt-BR"},
+	}
+
+	for _, test := range langTests {
+		fp := gofeed.NewParser()
+		feed, err := fp.ParseString(test.feed)
+		assert.Nil(t, err)
+		assert.Equal(t, test.raw, feed.Language)
+
+		fp = gofeed.NewParser()
+		fp.NormalizeLanguage = true
+		feed, err = fp.ParseString(test.feed)
+		assert.Nil(t, err)
+		assert.Equal(t, test.expected, feed.Language)
+	}
+}
+
+func TestParser_Close(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed.xml")
+	server, client := mockServerResponse(200, string(f))
+	defer server.Close()
+
+	// Closing without a client is a no-op
+	fp := gofeed.NewParser()
+	fp.Close()
+	assert.Nil(t, fp.Client)
+
+	// A client set by the caller is kept
+	fp.Client = client
+	feed, err := fp.ParseURL(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, "Feed Title", feed.Title)
+
+	fp.Close()
+	assert.Equal(t, client, fp.Client)
+
+	// The client created by the parser is cleared
+	fp = gofeed.NewParser()
+	fp.DialContext = (&net.Dialer{}).DialContext
+	feed, err = fp.ParseURL(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, "Feed Title", feed.Title)
+	assert.NotNil(t, fp.Client)
+
+	fp.Close()
+	fp.Close()
+	assert.Nil(t, fp.Client)
+}
+
+func TestParser_Parse_TrimSpace(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/universal/rss_feed_whitespace.xml")
+
+	fp := gofeed.NewParser()
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Equal(t, "Feed Title", feed.Title)
+	assert.Equal(t, "A\t tab   indented\n      description", feed.Description)
+	assert.Equal(t, "Item Title", feed.Items[0].Title)
+	// Only the ends of html descriptions are trimmed
+	assert.Equal(t, "<pre>func main() {\n    fmt.Println(\"hi\")\n}</pre>", feed.Items[1].Description)
+
+	// and content is left alone
+	atomFeed, err := fp.ParseString(`<feed xmlns="http://www.w3.org/2005/Atom"><entry><title>Code</title>
+<content type="html"><![CDATA[  <pre>a
+  b</pre>  ]]></content></entry></feed>`)
+	assert.Nil(t, err)
+	assert.Equal(t, "  <pre>a\n  b</pre>  ", atomFeed.Items[0].Content)
+
+	fp.TrimSpace = false
+	feed, err = fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Equal(t, "\n      Feed\n      Title\n    ", feed.Title)
+	assert.Equal(t, "Item\tTitle", feed.Items[0].Title)
+}
+
+func TestParser_Parse_Hooks(t *testing.T) {
+	feedData := `<rss version="2.0"><channel><title>Feed Title</title>
+<item><title>First</title><link>http://example.com/1?utm_source=feed</link></item>
+<item><title>Second</title><link>http://example.com/2</link></item>
+</channel></rss>`
+
+	var order []string
+	fp := gofeed.NewParser()
+	fp.ItemHook = func(item *gofeed.Item) {
+		order = append(order, "item")
+		item.Link = strings.Split(item.Link, "?")[0]
+		item.Custom = map[string]string{"source": "test"}
+	}
+	fp.FeedHook = func(feed *gofeed.Feed) {
+		order = append(order, "feed")
+		feed.Title = strings.ToUpper(feed.Title)
+	}
+
+	feed, err := fp.ParseString(feedData)
+	assert.Nil(t, err)
+	assert.Equal(t, "FEED TITLE", feed.Title)
+	assert.Equal(t, "http://example.com/1", feed.Items[0].Link)
+	assert.Equal(t, "http://example.com/2", feed.Items[1].Link)
+	assert.Equal(t, "test", feed.Items[1].Custom["source"])
+	assert.Equal(t, []string{"item", "item", "feed"}, order)
+}
+
+func TestParser_Parse_Translators(t *testing.T) {
+	f, _ := ioutil.ReadFile("testdata/parser/opml/opml_nested.xml")
+
+	fp := gofeed.NewParser()
+	_, err := fp.Parse(bytes.NewReader(f))
+	assert.NotNil(t, err)
+
+	fp.Translators = map[gofeed.FeedType]gofeed.Translator{
+		gofeed.FeedTypeOPML: translatorFunc(func(feed interface{}) (*gofeed.Feed, error) {
+			result := &gofeed.Feed{}
+			for _, outline := range feed.([]*opml.Outline) {
+				result.Items = append(result.Items, &gofeed.Item{Title: outline.Title})
+			}
+			return result, nil
+		}),
+	}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	if assert.Len(t, feed.Items, 2) {
+		assert.Equal(t, "News", feed.Items[0].Title)
+		assert.Equal(t, "The Example Blog", feed.Items[1].Title)
+	}
+
+	// The registry takes precedence over the per type fields
+	fp.RSSTranslator = translatorFunc(func(feed interface{}) (*gofeed.Feed, error) {
+		return &gofeed.Feed{Title: "field"}, nil
+	})
+	feed, err = fp.ParseString("<rss><channel><title>Feed Title</title></channel></rss>")
+	assert.Nil(t, err)
+	assert.Equal(t, "field", feed.Title)
+
+	fp.Translators[gofeed.FeedTypeRSS] = translatorFunc(func(feed interface{}) (*gofeed.Feed, error) {
+		return &gofeed.Feed{Title: "registry"}, nil
+	})
+	feed, err = fp.ParseString("<rss><channel><title>Feed Title</title></channel></rss>")
+	assert.Nil(t, err)
+	assert.Equal(t, "registry", feed.Title)
+}
+
 // Test Helpers
 
+type translatorFunc func(feed interface{}) (*gofeed.Feed, error)
+
+func (fn translatorFunc) Translate(feed interface{}) (*gofeed.Feed, error) {
+	return fn(feed)
+}
+
 func mockServerResponse(code int, body string) (*httptest.Server, *http.Client) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(code)
@@ -185,6 +1304,17 @@ func ExampleParser_ParseURL() {
 	fmt.Println(feed.Title)
 }
 
+func ExampleParser_ParseReaderWithType() {
+	// Read a piped RSS feed from stdin without relying on
+	// feed type detection.
+	fp := gofeed.NewParser()
+	feed, err := fp.ParseReaderWithType(os.Stdin, gofeed.FeedTypeRSS)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(feed.Title)
+}
+
 func ExampleParser_ParseString() {
 	feedData := `<rss version="2.0">
 <channel>
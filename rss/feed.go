@@ -57,6 +57,7 @@ type Item struct {
 	Source        *Source                  `json:"source,omitempty"`
 	DublinCoreExt *ext.DublinCoreExtension `json:"dcExt,omitempty"`
 	ITunesExt     *ext.ITunesItemExtension `json:"itunesExt,omitempty"`
+	MediaExt      *ext.MediaExtension      `json:"mediaExt,omitempty"`
 	Extensions    ext.Extensions           `json:"extensions,omitempty"`
 }
 
@@ -11,11 +11,28 @@ import (
 )
 
 // Parser is a RSS Parser
-type Parser struct{}
+type Parser struct {
+	// MaxItems, when positive, stops parsing once that many
+	// items were read.  The rest of the input is not read,
+	// so channel elements after the last item are lost.
+	MaxItems int
+
+	// ResolveRelativeLinks resolves relative item links
+	// against the xml:base in scope.
+	ResolveRelativeLinks bool
+
+	// CharsetReader converts the input of a non utf-8 feed
+	// to utf-8.  When nil, shared.NewReaderLabel is used.
+	CharsetReader func(charset string, input io.Reader) (io.Reader, error)
+}
 
 // Parse parses an xml feed into an rss.Feed
 func (rp *Parser) Parse(feed io.Reader) (*Feed, error) {
-	p := xpp.NewXMLPullParser(feed, false, shared.NewReaderLabel)
+	charsetReader := rp.CharsetReader
+	if charsetReader == nil {
+		charsetReader = shared.NewReaderLabel
+	}
+	p := xpp.NewXMLPullParser(feed, false, charsetReader)
 
 	_, err := shared.FindRoot(p)
 	if err != nil {
@@ -37,6 +54,8 @@ func (rp *Parser) parseRoot(p *xpp.XMLPullParser) (*Feed, error) {
 	var textinput *TextInput
 	var image *Image
 	items := []*Item{}
+	// Set once MaxItems is reached
+	truncated := false
 
 	ver := rp.parseVersion(p)
 
@@ -61,16 +80,39 @@ func (rp *Parser) parseRoot(p *xpp.XMLPullParser) (*Feed, error) {
 			name := strings.ToLower(p.Name)
 
 			if name == "channel" {
-				channel, err = rp.parseChannel(p)
+				n := len(items)
+				if channel != nil {
+					n += len(channel.Items)
+				}
+				next, err := rp.parseChannel(p, n)
 				if err != nil {
 					return nil, err
 				}
+				// Malformed feeds may repeat the channel, keep the
+				// first one's metadata and collect every item.
+				if channel == nil {
+					channel = next
+				} else {
+					channel.Items = append(channel.Items, next.Items...)
+				}
+				if rp.full(len(channel.Items) + len(items)) {
+					truncated = true
+					break
+				}
 			} else if name == "item" {
 				item, err := rp.parseItem(p)
 				if err != nil {
 					return nil, err
 				}
 				items = append(items, item)
+				n := len(items)
+				if channel != nil {
+					n += len(channel.Items)
+				}
+				if rp.full(n) {
+					truncated = true
+					break
+				}
 			} else if name == "textinput" {
 				textinput, err = rp.parseTextInput(p)
 				if err != nil {
@@ -87,10 +129,12 @@ func (rp *Parser) parseRoot(p *xpp.XMLPullParser) (*Feed, error) {
 		}
 	}
 
-	rssErr = p.Expect(xpp.EndTag, "rss")
-	rdfErr = p.Expect(xpp.EndTag, "rdf")
-	if rssErr != nil && rdfErr != nil {
-		return nil, fmt.Errorf("%s or %s", rssErr.Error(), rdfErr.Error())
+	if !truncated {
+		rssErr = p.Expect(xpp.EndTag, "rss")
+		rdfErr = p.Expect(xpp.EndTag, "rdf")
+		if rssErr != nil && rdfErr != nil {
+			return nil, fmt.Errorf("%s or %s", rssErr.Error(), rdfErr.Error())
+		}
 	}
 
 	if channel == nil {
@@ -114,7 +158,9 @@ func (rp *Parser) parseRoot(p *xpp.XMLPullParser) (*Feed, error) {
 	return channel, nil
 }
 
-func (rp *Parser) parseChannel(p *xpp.XMLPullParser) (rss *Feed, err error) {
+// parseChannel parses a channel, stopping once its items and
+// the parsed items already collected reach MaxItems.
+func (rp *Parser) parseChannel(p *xpp.XMLPullParser, parsed int) (rss *Feed, err error) {
 
 	if err = p.Expect(xpp.StartTag, "channel"); err != nil {
 		return nil, err
@@ -252,6 +298,9 @@ func (rp *Parser) parseChannel(p *xpp.XMLPullParser) (rss *Feed, err error) {
 					return nil, err
 				}
 				rss.Items = append(rss.Items, result)
+				if rp.full(parsed + len(rss.Items)) {
+					break
+				}
 			} else if name == "cloud" {
 				result, err := rp.parseCloud(p)
 				if err != nil {
@@ -284,8 +333,10 @@ func (rp *Parser) parseChannel(p *xpp.XMLPullParser) (rss *Feed, err error) {
 		}
 	}
 
-	if err = p.Expect(xpp.EndTag, "channel"); err != nil {
-		return nil, err
+	if !rp.full(parsed + len(rss.Items)) {
+		if err = p.Expect(xpp.EndTag, "channel"); err != nil {
+			return nil, err
+		}
 	}
 
 	if len(categories) > 0 {
@@ -307,6 +358,11 @@ func (rp *Parser) parseChannel(p *xpp.XMLPullParser) (rss *Feed, err error) {
 	return rss, nil
 }
 
+// full reports whether n items reach MaxItems
+func (rp *Parser) full(n int) bool {
+	return rp.MaxItems > 0 && n >= rp.MaxItems
+}
+
 func (rp *Parser) parseItem(p *xpp.XMLPullParser) (item *Item, err error) {
 
 	if err = p.Expect(xpp.StartTag, "item"); err != nil {
@@ -336,7 +392,7 @@ func (rp *Parser) parseItem(p *xpp.XMLPullParser) (item *Item, err error) {
 				if err != nil {
 					return nil, err
 				}
-				item.Extensions = ext
+				extensions = ext
 			} else if name == "title" {
 				result, err := shared.ParseText(p)
 				if err != nil {
@@ -350,11 +406,17 @@ func (rp *Parser) parseItem(p *xpp.XMLPullParser) (item *Item, err error) {
 				}
 				item.Description = result
 			} else if name == "link" {
+				// The xml:base of the link is out of scope
+				// once its text was read
+				base := p.BaseStack.Top()
 				result, err := shared.ParseText(p)
 				if err != nil {
 					return nil, err
 				}
 				item.Link = result
+				if rp.ResolveRelativeLinks {
+					item.Link = shared.ResolveURL(base, result)
+				}
 			} else if name == "author" {
 				result, err := shared.ParseText(p)
 				if err != nil {
@@ -423,6 +485,10 @@ func (rp *Parser) parseItem(p *xpp.XMLPullParser) (item *Item, err error) {
 		if dc, ok := item.Extensions["dc"]; ok {
 			item.DublinCoreExt = ext.NewDublinCoreExtension(dc)
 		}
+
+		if media, ok := item.Extensions["media"]; ok {
+			item.MediaExt = ext.NewMediaExtension(media)
+		}
 	}
 
 	if err = p.Expect(xpp.EndTag, "item"); err != nil {
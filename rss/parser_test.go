@@ -45,4 +45,36 @@ func TestParser_Parse(t *testing.T) {
 	}
 }
 
+func TestParser_ParseItemExtensions(t *testing.T) {
+	feed := `<rss version="2.0" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:media="http://search.yahoo.com/mrss/">
+<channel>
+<item>
+<dc:creator>Alice</dc:creator>
+<media:keywords>go</media:keywords>
+<dc:creator>Bob</dc:creator>
+<dc:creator>Alice</dc:creator>
+</item>
+</channel>
+</rss>`
+
+	fp := &rss.Parser{}
+	actual, err := fp.Parse(strings.NewReader(feed))
+	assert.Nil(t, err)
+	assert.Len(t, actual.Items, 1)
+
+	// Every extension element must survive the parse,
+	// duplicates included.
+	item := actual.Items[0]
+	assert.Len(t, item.Extensions["dc"]["creator"], 3)
+	assert.Len(t, item.Extensions["media"]["keywords"], 1)
+	assert.Equal(t, []string{"Alice", "Bob", "Alice"}, item.DublinCoreExt.Creator)
+
+	item.Extensions.Dedupe()
+	creators := item.Extensions["dc"]["creator"]
+	assert.Len(t, creators, 2)
+	assert.Equal(t, "Alice", creators[0].Value)
+	assert.Equal(t, "Bob", creators[1].Value)
+	assert.Len(t, item.Extensions["media"]["keywords"], 1)
+}
+
 // TODO: Examples
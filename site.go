@@ -0,0 +1,243 @@
+package gofeed
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/shuyaoyimei/gofeed/sitemap"
+)
+
+// defaultIndexDepth is how many levels of nested sitemap
+// indexes ParseSiteSitemaps follows when MaxIndexDepth is zero
+const defaultIndexDepth = 5
+
+// ParseSiteSitemaps collects every url a site publishes in its
+// sitemaps into one feed.  siteURL may be a bare domain such as
+// "example.com", which is fetched over https.  The sitemaps are
+// the ones listed in the site's robots.txt, or /sitemap.xml when
+// it lists none, and sitemap indexes are expanded up to
+// MaxIndexDepth levels deep.  Sitemaps and urls seen more than
+// once are only kept once.  The crawl is bounded by TotalDeadline
+// and, like ParseIndexResumable, stops at the first error,
+// returning the urls gathered so far.
+func (f *Parser) ParseSiteSitemaps(siteURL string) (*Feed, error) {
+	ctx := context.Background()
+	if f.TotalDeadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.TotalDeadline)
+		defer cancel()
+	}
+	return f.ParseSiteSitemapsWithContext(ctx, siteURL)
+}
+
+// ParseSiteSitemapsWithContext is ParseSiteSitemaps bound to
+// ctx instead of TotalDeadline.
+func (f *Parser) ParseSiteSitemapsWithContext(ctx context.Context, siteURL string) (*Feed, error) {
+	if !strings.Contains(siteURL, "://") {
+		siteURL = "https://" + siteURL
+	}
+	site, err := url.Parse(siteURL)
+	if err != nil {
+		return nil, err
+	}
+	if site.Host == "" {
+		return nil, errors.New("site url has no host")
+	}
+
+	sitemaps, err := f.discoverSitemaps(ctx, site)
+	if err != nil {
+		return nil, err
+	}
+	return f.crawlSitemaps(ctx, sitemaps, site.String())
+}
+
+// ParseSitemapList fetches and merges the sitemaps listed in r,
+// a plain text inventory with one sitemap url per line, such as
+// the exports of webmaster tools.  Blank lines and lines starting
+// with a # are ignored.  The sitemaps are crawled like the ones
+// ParseSiteSitemaps discovers.
+func (f *Parser) ParseSitemapList(r io.Reader) (*Feed, error) {
+	ctx := context.Background()
+	if f.TotalDeadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.TotalDeadline)
+		defer cancel()
+	}
+	return f.ParseSitemapListWithContext(ctx, r)
+}
+
+// ParseSitemapListWithContext is ParseSitemapList bound to ctx
+// instead of TotalDeadline.
+func (f *Parser) ParseSitemapListWithContext(ctx context.Context, r io.Reader) (*Feed, error) {
+	sitemaps := []string{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		link, err := url.Parse(line)
+		if err != nil || !link.IsAbs() {
+			return nil, fmt.Errorf("line %d of the sitemap list is not an absolute url: %q", n, line)
+		}
+		sitemaps = append(sitemaps, link.String())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return f.crawlSitemaps(ctx, sitemaps, "")
+}
+
+// crawlSitemaps merges the urls of sitemaps, and of the
+// sitemaps of the indexes among them, into one feed.
+func (f *Parser) crawlSitemaps(ctx context.Context, sitemaps []string, base string) (*Feed, error) {
+	c := &siteCrawl{
+		f:        f,
+		merged:   &sitemap.Feed{},
+		sitemaps: map[string]bool{},
+		links:    map[string]bool{},
+	}
+	err := c.expand(ctx, sitemaps, 0)
+	return f.translateCrawl(c.merged, base, err)
+}
+
+// discoverSitemaps returns the sitemaps listed in the robots.txt
+// of site, or its /sitemap.xml when robots.txt lists none or
+// can't be fetched.
+func (f *Parser) discoverSitemaps(ctx context.Context, site *url.URL) ([]string, error) {
+	robots := &url.URL{Scheme: site.Scheme, Host: site.Host, Path: "/robots.txt"}
+	sitemaps, err := f.robotsSitemaps(ctx, robots)
+	if err != nil {
+		// A missing robots.txt is common, anything else still
+		// stops the crawl, e.g. a spent host budget.
+		if _, ok := err.(HTTPError); !ok {
+			return nil, err
+		}
+	}
+	if len(sitemaps) == 0 {
+		fallback := &url.URL{Scheme: site.Scheme, Host: site.Host, Path: "/sitemap.xml"}
+		sitemaps = []string{fallback.String()}
+	}
+	return sitemaps, nil
+}
+
+// robotsSitemaps fetches a robots.txt and returns the urls of
+// its Sitemap lines, resolved against it.
+func (f *Parser) robotsSitemaps(ctx context.Context, robots *url.URL) (sitemaps []string, err error) {
+	if err := f.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer f.release()
+
+	body, meta, err := f.open(ctx, robots.String())
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		ce := body.Close()
+		if ce != nil && err == nil {
+			err = ce
+		}
+	}()
+
+	err = f.readLimited(body, meta, func(r io.Reader) (err error) {
+		sitemaps, err = scanRobotsSitemaps(r, robots)
+		return err
+	})
+	return sitemaps, err
+}
+
+// scanRobotsSitemaps returns the urls of the Sitemap lines of a
+// robots.txt, resolved against its url.
+func scanRobotsSitemaps(r io.Reader, robots *url.URL) (sitemaps []string, err error) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		i := strings.Index(line, ":")
+		if i < 0 || !strings.EqualFold(strings.TrimSpace(line[:i]), "sitemap") {
+			continue
+		}
+		link, err := robots.Parse(strings.TrimSpace(line[i+1:]))
+		if err != nil || link.String() == "" {
+			continue
+		}
+		sitemaps = append(sitemaps, link.String())
+	}
+	return sitemaps, scanner.Err()
+}
+
+// siteCrawl merges the urls of a tree of sitemaps
+type siteCrawl struct {
+	f        *Parser
+	merged   *sitemap.Feed
+	sitemaps map[string]bool
+	links    map[string]bool
+}
+
+// expand fetches the sitemaps at urls, merging the urls of
+// urlsets and expanding indexes, which are depth levels deep.
+func (c *siteCrawl) expand(ctx context.Context, urls []string, depth int) error {
+	maxDepth := c.f.MaxIndexDepth
+	if maxDepth == 0 {
+		maxDepth = defaultIndexDepth
+	}
+
+	for _, link := range urls {
+		if c.sitemaps[link] {
+			continue
+		}
+		c.sitemaps[link] = true
+
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		sf, err := c.f.fetchSitemap(ctx, link)
+		if err != nil {
+			return err
+		}
+
+		if len(sf.Sitemaps) > 0 {
+			if depth >= maxDepth {
+				continue
+			}
+			children := make([]string, 0, len(sf.Sitemaps))
+			for _, ref := range sf.Sitemaps {
+				children = append(children, ref.Link)
+			}
+			if err := c.expand(ctx, children, depth+1); err != nil {
+				return err
+			}
+			continue
+		}
+
+		c.merge(sf)
+	}
+	return nil
+}
+
+func (c *siteCrawl) merge(sf *sitemap.Feed) {
+	if c.merged.Title == "" {
+		c.merged.Title = sf.Title
+	}
+	if c.merged.Language == "" {
+		c.merged.Language = sf.Language
+	}
+	if c.merged.Version == "" {
+		c.merged.Version = sf.Version
+	}
+	for _, item := range sf.Items {
+		if c.links[item.Link] {
+			continue
+		}
+		c.links[item.Link] = true
+		c.merged.Items = append(c.merged.Items, item)
+	}
+}
@@ -0,0 +1,170 @@
+package gofeed_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/shuyaoyimei/gofeed"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParser_ParseSiteSitemaps(t *testing.T) {
+	fetched := map[string]int{}
+	robots := true
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched[r.URL.Path]++
+		urlset := func(paths ...string) {
+			fmt.Fprint(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+			for _, path := range paths {
+				fmt.Fprintf(w, "<url><loc>http://www.example.com%s</loc></url>", path)
+			}
+			fmt.Fprint(w, "</urlset>")
+		}
+		index := func(paths ...string) {
+			fmt.Fprint(w, `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">`)
+			for _, path := range paths {
+				fmt.Fprintf(w, "<sitemap><loc>%s%s</loc></sitemap>", server.URL, path)
+			}
+			fmt.Fprint(w, "</sitemapindex>")
+		}
+
+		switch r.URL.Path {
+		case "/robots.txt":
+			if !robots {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private\n\n")
+			fmt.Fprintf(w, "Sitemap: %s/index.xml # the index\n", server.URL)
+			fmt.Fprint(w, "sitemap: /news.xml\n")
+		case "/index.xml":
+			index("/a.xml", "/nested.xml", "/news.xml")
+		case "/nested.xml":
+			// Lists itself, which must not loop
+			index("/b.xml", "/nested.xml")
+		case "/a.xml":
+			urlset("/1", "/2")
+		case "/b.xml":
+			urlset("/2", "/3")
+		case "/news.xml":
+			urlset("/4")
+		case "/sitemap.xml":
+			urlset("/fallback")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	links := func(feed *gofeed.Feed) []string {
+		links := []string{}
+		for _, item := range feed.Items {
+			links = append(links, item.Link)
+		}
+		sort.Strings(links)
+		return links
+	}
+
+	fp := gofeed.NewParser()
+	feed, err := fp.ParseSiteSitemaps(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"http://www.example.com/1",
+		"http://www.example.com/2",
+		"http://www.example.com/3",
+		"http://www.example.com/4",
+	}, links(feed))
+	assert.Equal(t, 1, fetched["/news.xml"])
+	assert.Equal(t, 1, fetched["/nested.xml"])
+	assert.Equal(t, 0, fetched["/sitemap.xml"])
+
+	// Indexes past the depth limit are dropped
+	fp = gofeed.NewParser()
+	fp.MaxIndexDepth = 1
+	feed, err = fp.ParseSiteSitemaps(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"http://www.example.com/1",
+		"http://www.example.com/2",
+		"http://www.example.com/4",
+	}, links(feed))
+
+	// Without a robots.txt /sitemap.xml is used
+	robots = false
+	feed, err = fp.ParseSiteSitemaps(server.URL)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"http://www.example.com/fallback"}, links(feed))
+
+	// The host budget and the context still apply
+	robots = true
+	fp = gofeed.NewParser()
+	fp.HostRequestBudget = 3
+	feed, err = fp.ParseSiteSitemaps(server.URL)
+	assert.IsType(t, gofeed.HostBudgetError{}, err)
+	assert.Equal(t, []string{"http://www.example.com/1", "http://www.example.com/2"}, links(feed))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = gofeed.NewParser().ParseSiteSitemapsWithContext(ctx, server.URL)
+	assert.Equal(t, context.Canceled, err)
+}
+
+func TestParser_ParseSitemapList(t *testing.T) {
+	fetched := map[string]int{}
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched[r.URL.Path]++
+		switch r.URL.Path {
+		case "/index.xml":
+			fmt.Fprintf(w, `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><sitemap><loc>%s/b.xml</loc></sitemap></sitemapindex>`, server.URL)
+		case "/a.xml":
+			fmt.Fprint(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>http://www.example.com/1</loc></url><url><loc>http://www.example.com/2</loc></url></urlset>`)
+		case "/b.xml":
+			fmt.Fprint(w, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>http://www.example.com/2</loc></url><url><loc>http://www.example.com/3</loc></url></urlset>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	list := fmt.Sprintf(`# Sitemap inventory
+%[1]s/a.xml
+
+  # the index lists b.xml
+  %[1]s/index.xml  
+%[1]s/a.xml
+`, server.URL)
+
+	fp := gofeed.NewParser()
+	feed, err := fp.ParseSitemapList(strings.NewReader(list))
+	assert.Nil(t, err)
+	links := []string{}
+	for _, item := range feed.Items {
+		links = append(links, item.Link)
+	}
+	assert.Equal(t, []string{
+		"http://www.example.com/1",
+		"http://www.example.com/2",
+		"http://www.example.com/3",
+	}, links)
+	assert.Equal(t, 1, fetched["/a.xml"])
+	assert.Equal(t, 1, fetched["/b.xml"])
+
+	// The host budget still applies
+	fp = gofeed.NewParser()
+	fp.HostRequestBudget = 1
+	feed, err = fp.ParseSitemapList(strings.NewReader(list))
+	assert.IsType(t, gofeed.HostBudgetError{}, err)
+	assert.Len(t, feed.Items, 2)
+
+	_, err = gofeed.NewParser().ParseSitemapList(strings.NewReader("/relative.xml\n"))
+	assert.EqualError(t, err, `line 1 of the sitemap list is not an absolute url: "/relative.xml"`)
+}
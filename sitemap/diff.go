@@ -0,0 +1,114 @@
+package sitemap
+
+import (
+	"net/url"
+	"strings"
+)
+
+// FeedDiff is the difference between two crawls of
+// the same sitemap.
+type FeedDiff struct {
+	// Added are the items only found in the after feed
+	Added []*Item
+	// Removed are the items only found in the before feed
+	Removed []*Item
+	// Updated are the items of the after feed whose
+	// LastModParsed advanced since the before feed
+	Updated []*Item
+}
+
+// DiffFeeds compares two crawls of a sitemap keyed on the
+// item Link.  A nil feed is treated as an empty one.
+func DiffFeeds(before, after *Feed) FeedDiff {
+	return DiffFeedsFunc(before, after, nil)
+}
+
+// DiffFeedsFunc is like DiffFeeds, but items are keyed on
+// the result of key applied to their Link (e.g. NormalizeLink).
+// A nil key uses the Link as is.
+func DiffFeedsFunc(before, after *Feed, key func(link string) string) FeedDiff {
+	if key == nil {
+		key = func(link string) string { return link }
+	}
+
+	beforeItems := indexItems(before, key)
+	afterItems := indexItems(after, key)
+
+	diff := FeedDiff{}
+	if after != nil {
+		for _, item := range after.Items {
+			k := key(item.Link)
+			if afterItems[k] != item {
+				// Only the first item of a link counts
+				continue
+			}
+
+			prev, ok := beforeItems[k]
+			if !ok {
+				diff.Added = append(diff.Added, item)
+			} else if lastModAdvanced(prev, item) {
+				diff.Updated = append(diff.Updated, item)
+			}
+		}
+	}
+
+	if before != nil {
+		for _, item := range before.Items {
+			k := key(item.Link)
+			if beforeItems[k] != item {
+				continue
+			}
+
+			if _, ok := afterItems[k]; !ok {
+				diff.Removed = append(diff.Removed, item)
+			}
+		}
+	}
+
+	return diff
+}
+
+// NormalizeLink lowercases the scheme and host of a link
+// and drops its fragment, so trivially different spellings
+// of the same url compare equal.  Links that fail to parse
+// are only trimmed.
+func NormalizeLink(link string) string {
+	link = strings.TrimSpace(link)
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment = ""
+	if u.Path == "" && u.Host != "" {
+		u.Path = "/"
+	}
+	return u.String()
+}
+
+func indexItems(feed *Feed, key func(link string) string) map[string]*Item {
+	items := map[string]*Item{}
+	if feed == nil {
+		return items
+	}
+
+	for _, item := range feed.Items {
+		k := key(item.Link)
+		if _, ok := items[k]; !ok {
+			items[k] = item
+		}
+	}
+	return items
+}
+
+func lastModAdvanced(before, after *Item) bool {
+	if after.LastModParsed == nil {
+		return false
+	}
+	if before.LastModParsed == nil {
+		return true
+	}
+	return after.LastModParsed.After(*before.LastModParsed)
+}
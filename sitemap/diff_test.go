@@ -0,0 +1,71 @@
+package sitemap_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shuyaoyimei/gofeed/sitemap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffFeeds(t *testing.T) {
+	jan := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	before := &sitemap.Feed{Items: []*sitemap.Item{
+		{Link: "http://example.com/kept", LastModParsed: &jan},
+		{Link: "http://example.com/updated", LastModParsed: &jan},
+		{Link: "http://example.com/dated", LastModParsed: nil},
+		{Link: "http://example.com/undated", LastModParsed: &jan},
+		{Link: "http://example.com/removed"},
+	}}
+	after := &sitemap.Feed{Items: []*sitemap.Item{
+		{Link: "http://example.com/kept", LastModParsed: &jan},
+		{Link: "http://example.com/updated", LastModParsed: &feb},
+		{Link: "http://example.com/dated", LastModParsed: &jan},
+		{Link: "http://example.com/undated", LastModParsed: nil},
+		{Link: "http://example.com/added"},
+		{Link: "http://example.com/added"},
+	}}
+
+	diff := sitemap.DiffFeeds(before, after)
+	assert.Equal(t, []string{"http://example.com/added"}, links(diff.Added))
+	assert.Equal(t, []string{"http://example.com/removed"}, links(diff.Removed))
+	assert.Equal(t, []string{"http://example.com/updated", "http://example.com/dated"}, links(diff.Updated))
+
+	// Nil feeds are empty
+	diff = sitemap.DiffFeeds(nil, after)
+	assert.Len(t, diff.Added, 5)
+	assert.Nil(t, diff.Removed)
+	diff = sitemap.DiffFeeds(before, nil)
+	assert.Nil(t, diff.Added)
+	assert.Len(t, diff.Removed, 5)
+}
+
+func TestDiffFeedsFunc_NormalizeLink(t *testing.T) {
+	before := &sitemap.Feed{Items: []*sitemap.Item{
+		{Link: "HTTP://Example.com/Page#top"},
+		{Link: "http://example.com"},
+	}}
+	after := &sitemap.Feed{Items: []*sitemap.Item{
+		{Link: "http://example.com/Page"},
+		{Link: " http://EXAMPLE.com/ "},
+	}}
+
+	diff := sitemap.DiffFeeds(before, after)
+	assert.Len(t, diff.Added, 2)
+	assert.Len(t, diff.Removed, 2)
+
+	diff = sitemap.DiffFeedsFunc(before, after, sitemap.NormalizeLink)
+	assert.Nil(t, diff.Added)
+	assert.Nil(t, diff.Removed)
+	assert.Nil(t, diff.Updated)
+}
+
+func links(items []*sitemap.Item) []string {
+	var result []string
+	for _, item := range items {
+		result = append(result, item.Link)
+	}
+	return result
+}
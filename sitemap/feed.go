@@ -2,17 +2,29 @@ package sitemap
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 
 	"github.com/shuyaoyimei/gofeed/extensions"
 )
 
-// Feed is an RSS Feed
+// Feed is an RSS Feed.  LastModified is the Last-Modified
+// header of the response a sitemap fetched from a url came
+// with, a freshness hint for sitemaps without lastmod.  Root
+// and Namespaces are only set by ParseHeader: the name of the
+// root element, urlset or sitemapindex, and the namespaces it
+// declares by prefix, "" being the default namespace.
 type Feed struct {
-	Title    string  `json:"title,omitempty"`
-	Items    []*Item `json:"items,omitempty"`
-	Language string  `json:"language,omitempty"`
-	Version  string  `json:"version,omitempty"`
+	Title        string            `json:"title,omitempty"`
+	Items        []*Item           `json:"items,omitempty"`
+	Sitemaps     []*SitemapRef     `json:"sitemaps,omitempty"`
+	Language     string            `json:"language,omitempty"`
+	Version      string            `json:"version,omitempty"`
+	Extensions   ext.Extensions    `json:"extensions,omitempty"`
+	Warnings     []string          `json:"warnings,omitempty"`
+	LastModified *time.Time        `json:"lastModified,omitempty"`
+	Root         string            `json:"root,omitempty"`
+	Namespaces   map[string]string `json:"namespaces,omitempty"`
 }
 
 func (f Feed) String() string {
@@ -20,14 +32,189 @@ func (f Feed) String() string {
 	return string(json)
 }
 
+// Publications returns the distinct news publication names of
+// the items with the number of items of each.  Feed.Title only
+// holds the first one, while aggregated news sitemaps may list
+// several.
+func (f Feed) Publications() map[string]int {
+	publications := map[string]int{}
+	for _, item := range f.Items {
+		if item.Publication != "" {
+			publications[item.Publication]++
+		}
+	}
+	return publications
+}
+
+// AllLinks returns the urls the item points to: its locs,
+// its image locs, the content and player locs of its videos
+// and the hrefs of its alternates, in that order and without
+// duplicates or empty values.
+func (i *Item) AllLinks() []string {
+	links := []string{}
+	seen := map[string]bool{}
+	add := func(link string) {
+		if link != "" && !seen[link] {
+			seen[link] = true
+			links = append(links, link)
+		}
+	}
+
+	add(i.Link)
+	for _, loc := range i.Locs {
+		add(loc)
+	}
+	if i.Image != nil {
+		add(i.Image.Link)
+	}
+	for _, image := range i.Images {
+		add(image.Link)
+	}
+	for _, video := range i.Videos {
+		add(video.ContentLoc)
+		add(video.PlayerLoc)
+	}
+	for _, alt := range i.Alternates {
+		add(alt.Link)
+	}
+	return links
+}
+
+// NextCrawlAfter suggests when the url is next worth crawling:
+// its lastmod advanced by its changefreq, e.g. a day for daily.
+// "always" urls are due at their lastmod.  The zero time, i.e.
+// crawl now, is returned when the url has no lastmod or no
+// known changefreq, and a time in the year 9999 for "never".
+func (i *Item) NextCrawlAfter() time.Time {
+	freq := strings.ToLower(strings.TrimSpace(i.ChangeFreq))
+	if freq == "never" {
+		return time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+	}
+	if i.LastModParsed == nil {
+		return time.Time{}
+	}
+
+	lastMod := *i.LastModParsed
+	switch freq {
+	case "always":
+		return lastMod
+	case "hourly":
+		return lastMod.Add(time.Hour)
+	case "daily":
+		return lastMod.AddDate(0, 0, 1)
+	case "weekly":
+		return lastMod.AddDate(0, 0, 7)
+	case "monthly":
+		return lastMod.AddDate(0, 1, 0)
+	case "yearly":
+		return lastMod.AddDate(1, 0, 0)
+	}
+	return time.Time{}
+}
+
+// AlternateFor returns the url of the xhtml:link alternate
+// declared for the given language on the item with the given
+// link.  The language is compared case-insensitively and an
+// empty string is returned when no such alternate exists.
+func (f Feed) AlternateFor(link, lang string) string {
+	for _, item := range f.Items {
+		if item.Link != link {
+			continue
+		}
+		for _, alt := range item.Alternates {
+			if strings.EqualFold(alt.Lang, lang) {
+				return alt.Link
+			}
+		}
+	}
+	return ""
+}
+
+// GroupByAlternateSet groups the items that are alternates of
+// each other, i.e. a page and its translations.  Two items are
+// in the same group when their links and xhtml:link alternates
+// are connected.  Items without alternates are in a group of
+// their own.  Groups are ordered by their first item and keep
+// the feed order of their items.
+func (f Feed) GroupByAlternateSet() [][]*Item {
+	// Union-find over the links of all items and alternates
+	parent := map[string]string{}
+	var find func(link string) string
+	find = func(link string) string {
+		p, ok := parent[link]
+		if !ok {
+			parent[link] = link
+			return link
+		}
+		if p == link {
+			return link
+		}
+		root := find(p)
+		parent[link] = root
+		return root
+	}
+	union := func(a, b string) {
+		ra, rb := find(a), find(b)
+		if ra != rb {
+			parent[rb] = ra
+		}
+	}
+
+	for _, item := range f.Items {
+		find(item.Link)
+		for _, alt := range item.Alternates {
+			union(item.Link, alt.Link)
+		}
+	}
+
+	groups := [][]*Item{}
+	index := map[string]int{}
+	for _, item := range f.Items {
+		root := find(item.Link)
+		i, ok := index[root]
+		if !ok {
+			i = len(groups)
+			index[root] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], item)
+	}
+	return groups
+}
+
 // Item is an RSS Item
 type Item struct {
-	Title         string         `json:"title,omitempty"`
-	Link          string         `json:"link,omitempty"`
-	Image         *Image         `json:"image,omitempty"`
-	PubDate       string         `json:"pubDate,omitempty"`
-	PubDateParsed *time.Time     `json:"pubDateParsed,omitempty"`
-	Extensions    ext.Extensions `json:"extensions,omitempty"`
+	Title         string            `json:"title,omitempty"`
+	Link          string            `json:"link,omitempty"`
+	Locs          []string          `json:"locs,omitempty"`
+	Image         *Image            `json:"image,omitempty"`
+	Images        []*Image          `json:"images,omitempty"`
+	Videos        []*Video          `json:"videos,omitempty"`
+	Geo           *GeoExtension     `json:"geo,omitempty"`
+	Alternates    []*Alternate      `json:"alternates,omitempty"`
+	Publication   string            `json:"publication,omitempty"`
+	PubDate       string            `json:"pubDate,omitempty"`
+	PubDateParsed *time.Time        `json:"pubDateParsed,omitempty"`
+	LastMod       string            `json:"lastmod,omitempty"`
+	LastModParsed *time.Time        `json:"lastmodParsed,omitempty"`
+	ChangeFreq    string            `json:"changefreq,omitempty"`
+	Priority      string            `json:"priority,omitempty"`
+	Extensions    ext.Extensions    `json:"extensions,omitempty"`
+	Attrs         map[string]string `json:"attrs,omitempty"`
+}
+
+// SitemapRef is a child sitemap listed in a sitemap index
+type SitemapRef struct {
+	Link          string     `json:"link,omitempty"`
+	LastMod       string     `json:"lastmod,omitempty"`
+	LastModParsed *time.Time `json:"lastmodParsed,omitempty"`
+}
+
+// Alternate is a localized version of an item declared
+// with an xhtml:link rel="alternate" element
+type Alternate struct {
+	Link string `json:"link,omitempty"`
+	Lang string `json:"hreflang,omitempty"`
 }
 
 // Image is an image that represents the feed
@@ -35,7 +222,41 @@ type Image struct {
 	Link string `json:"link,omitempty"`
 }
 
-//News is a mid status for item
+// Video is a video:video entry of a video sitemap.  The
+// element is also kept in the item extensions.
+type Video struct {
+	ThumbnailLoc string            `json:"thumbnailLoc,omitempty"`
+	Title        string            `json:"title,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	ContentLoc   string            `json:"contentLoc,omitempty"`
+	PlayerLoc    string            `json:"playerLoc,omitempty"`
+	Duration     string            `json:"duration,omitempty"`
+	Restriction  *VideoRestriction `json:"restriction,omitempty"`
+	Platform     *VideoPlatform    `json:"platform,omitempty"`
+}
+
+// VideoRestriction lists the countries a video may, for an
+// "allow" relationship, or may not, for "deny", be shown in,
+// as ISO 3166 codes.
+type VideoRestriction struct {
+	Relationship string   `json:"relationship,omitempty"`
+	Countries    []string `json:"countries,omitempty"`
+}
+
+// VideoPlatform lists the platforms, web, mobile or tv, a
+// video may, or for a "deny" relationship may not, be shown on.
+type VideoPlatform struct {
+	Relationship string   `json:"relationship,omitempty"`
+	Platforms    []string `json:"platforms,omitempty"`
+}
+
+// GeoExtension is a geo sitemap entry that references
+// a geographic content file (e.g. KML)
+type GeoExtension struct {
+	Format string `json:"format,omitempty"`
+}
+
+// News is a mid status for item
 type News struct {
 	Name            string `json:"name,omitempty"`
 	Title           string `json:"title,omitempty"`
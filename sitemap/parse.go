@@ -1,9 +1,14 @@
 package sitemap
 
 import (
+	"context"
+	"encoding/xml"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/mmcdole/goxpp"
 	"github.com/shuyaoyimei/gofeed/extensions"
@@ -11,31 +16,489 @@ import (
 )
 
 // Parser is a Sitemap Parser
-type Parser struct{}
+type Parser struct {
+	// CollectWarnings records non-fatal data quality issues
+	// (unknown changefreq values, out of range priorities,
+	// unparseable dates, skipped elements) in Feed.Warnings.
+	CollectWarnings bool
+
+	// NewsOnly skips url entries that don't contain a
+	// news:news block, for crawlers that only want news.
+	NewsOnly bool
+
+	// NormalizeLanguage canonicalizes news publication
+	// languages to BCP-47 (e.g. "zh_cn" becomes "zh-CN").
+	// When false the raw value is kept.
+	NormalizeLanguage bool
+
+	// StripHTML removes html tags and entities from news
+	// titles, leaving plain text.  When false the raw title
+	// is kept.
+	StripHTML bool
+
+	// MaxItems, when positive, stops parsing once that many
+	// urls were read, leaving the rest of the input unread.
+	// ParseStream instead fails on sitemaps with more urls.
+	MaxItems int
+
+	// MaxLocLength, when positive, makes Parse fail on url,
+	// index sitemap and image locs longer than it, counted in
+	// characters.  When zero long locs are kept as is, with a
+	// warning past the 2048 characters common crawlers accept.
+	MaxLocLength int
+
+	// ClampFutureDates replaces news publication dates more
+	// than FutureDateTolerance past the parse time with the
+	// parse time, for publishers with skewed clocks.  The raw
+	// PubDate is kept either way.
+	ClampFutureDates bool
+
+	// FutureDateTolerance is how far in the future a publication
+	// date may be before it is considered skewed.
+	FutureDateTolerance time.Duration
+
+	// Now returns the current time future dates are checked
+	// against.  When nil, time.Now is used.
+	Now func() time.Time
+
+	// ParseExtensions, when not empty, lists the only extension
+	// namespaces to parse, including the modeled news, image,
+	// video, geo and xhtml ones.  Elements of any other namespace
+	// are skipped unread.
+	ParseExtensions []string
+
+	// SkipExtensions lists extension namespaces whose elements
+	// are skipped unread, e.g. the image namespace for a crawler
+	// that only wants news.
+	SkipExtensions []string
+
+	// CaptureAllLocs collects every loc of a url entry in
+	// Item.Locs, for generators that emit a second loc such as
+	// a canonical or amp variant.  Item.Link is the first loc
+	// either way, the others being skipped when false.
+	CaptureAllLocs bool
+
+	// CharsetReader converts the input of a non utf-8 sitemap
+	// to utf-8.  When nil, shared.NewReaderLabel is used.
+	CharsetReader func(charset string, input io.Reader) (io.Reader, error)
+
+	warnings []string
+	now      time.Time
+	// emit, when set, receives the urls in place of Feed.Items
+	emit func(*Item) error
+}
+
+// Namespaces of the sitemap protocol and the sitemap
+// extensions modeled by the parser
+const (
+	sitemapNS = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	newsNS    = "http://www.google.com/schemas/sitemap-news/0.9"
+	imageNS   = "http://www.google.com/schemas/sitemap-image/1.1"
+	videoNS   = "http://www.google.com/schemas/sitemap-video/1.1"
+	geoNS     = "http://www.google.com/geo/schemas/sitemap/1.0"
+	xhtmlNS   = "http://www.w3.org/1999/xhtml"
+)
+
+// locWarnLength is the loc length past which many crawlers
+// refuse a url
+const locWarnLength = 2048
+
+// changeFreqs are the changefreq values allowed by the
+// sitemaps.org protocol
+var changeFreqs = map[string]bool{
+	"always":  true,
+	"hourly":  true,
+	"daily":   true,
+	"weekly":  true,
+	"monthly": true,
+	"yearly":  true,
+	"never":   true,
+}
 
 // Parse parses an xml feed into an sitemap.Feed
 func (sp *Parser) Parse(feed io.Reader) (*Feed, error) {
-	p := xpp.NewXMLPullParser(feed, false, shared.NewReaderLabel)
+	return sp.parse(feed, nil)
+}
 
-	_, err := shared.FindRoot(p)
+// ParseHeader reads a sitemap only up to its root element and
+// returns a feed with its metadata and no urls, for classifying
+// many sitemaps cheaply.  The feed holds the Root, Version,
+// Namespaces and the Language of an xml:lang on the root.
+// Nothing past the root start tag is parsed, so the rest of
+// the sitemap isn't checked for errors.
+func (sp *Parser) ParseHeader(r io.Reader) (*Feed, error) {
+	ps, p, err := sp.start(r, nil)
 	if err != nil {
 		return nil, err
 	}
-	return sp.parseRoot(p)
+
+	name := strings.ToLower(p.Name)
+	if name != "urlset" && name != "sitemapindex" {
+		return nil, fmt.Errorf("expected a urlset or sitemapindex root, found <%s>", p.Name)
+	}
+
+	header := &Feed{
+		Root:       name,
+		Version:    ps.parseVersion(p),
+		Namespaces: map[string]string{},
+	}
+	for _, attr := range p.Attrs {
+		if attr.Name.Space == "xmlns" {
+			header.Namespaces[attr.Name.Local] = attr.Value
+		} else if isDeclaration(attr) {
+			header.Namespaces[""] = attr.Value
+		}
+	}
+	if lang := p.Attribute("lang"); lang != "" {
+		if ps.NormalizeLanguage {
+			lang = shared.NormalizeLanguage(lang)
+		}
+		header.Language = lang
+	}
+	return header, nil
+}
+
+// CountURLs returns the number of url entries of a urlset, or
+// of sitemap entries of an index, reading through r without
+// parsing the entries, which is much cheaper than Parse for
+// sizing up large sitemaps.  As the entries aren't read, NewsOnly
+// and MaxItems don't apply.
+func (sp *Parser) CountURLs(r io.Reader) (int, error) {
+	charsetReader := sp.CharsetReader
+	if charsetReader == nil {
+		charsetReader = shared.NewReaderLabel
+	}
+	// The raw tokens skip the namespace translation and
+	// attribute copies the pull parser makes for every tag.
+	d := xml.NewDecoder(r)
+	d.Strict = false
+	d.CharsetReader = charsetReader
+
+	root, entry := "", ""
+	count, depth := 0, 0
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF && root == "" {
+			return 0, fmt.Errorf("Failed to find root node before document end.")
+		}
+		if err == io.EOF {
+			return 0, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 1 {
+				root = t.Name.Local
+				switch strings.ToLower(root) {
+				case "urlset":
+					entry = "url"
+				case "sitemapindex":
+					entry = "sitemap"
+				default:
+					return 0, fmt.Errorf("expected a urlset or sitemapindex root, found <%s>", root)
+				}
+			} else if depth == 2 && strings.EqualFold(t.Name.Local, entry) {
+				count++
+			}
+		case xml.EndElement:
+			depth--
+			if depth == 0 {
+				return count, nil
+			}
+		}
+	}
+}
+
+// ParseStream parses a sitemap like Parse, but hands each url
+// to fn as soon as it is read instead of collecting them, so
+// memory stays flat on sitemaps of any size.  The returned feed
+// holds everything but the Items.  MaxItems, when positive,
+// makes ParseStream fail once a url past the first MaxItems is
+// found.  An error returned by fn stops the parse and is
+// returned as is.
+func (sp *Parser) ParseStream(feed io.Reader, fn func(*Item) error) (*Feed, error) {
+	return sp.ParseStreamWithContext(context.Background(), feed, fn)
+}
+
+// ParseStreamWithContext is ParseStream bound to ctx: once ctx
+// is done no further urls are handed to fn and ctx.Err() is
+// returned.
+func (sp *Parser) ParseStreamWithContext(ctx context.Context, feed io.Reader, fn func(*Item) error) (*Feed, error) {
+	count := 0
+	return sp.parse(feed, func(item *Item) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if sp.MaxItems > 0 && count >= sp.MaxItems {
+			return fmt.Errorf("sitemap has more than the maximum of %d urls", sp.MaxItems)
+		}
+		count++
+		return fn(item)
+	})
+}
+
+// ParseChan parses a sitemap like ParseStream, sending each
+// url on the returned item channel, which is closed at the end
+// of the parse.  The error channel then delivers the outcome,
+// nil on success, once.  The parse blocks until every url was
+// received, so callers that may stop reading early must use
+// ParseChanWithContext and cancel its context instead, or the
+// parsing goroutine is leaked.
+func (sp *Parser) ParseChan(r io.Reader) (<-chan *Item, <-chan error) {
+	return sp.ParseChanWithContext(context.Background(), r)
+}
+
+// ParseChanWithContext is ParseChan bound to ctx: once ctx is
+// done the parse stops, even while blocked on a url no one
+// receives, and ctx.Err() is delivered on the error channel.
+func (sp *Parser) ParseChanWithContext(ctx context.Context, r io.Reader) (<-chan *Item, <-chan error) {
+	items := make(chan *Item)
+	errs := make(chan error, 1)
+	go func() {
+		_, err := sp.ParseStreamWithContext(ctx, r, func(item *Item) error {
+			select {
+			case items <- item:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		errs <- err
+		close(items)
+		close(errs)
+	}()
+	return items, errs
+}
+
+// ItemIterator hands out the urls of a sitemap one at a time,
+// reading the input only as far as needed for the next url.
+type ItemIterator struct {
+	ur    *urlSetReader
+	count int
+	done  bool
+}
+
+// Iterator starts a pull parse of the urlset in r, for callers
+// driving the parse loop themselves.  Sitemap indexes have no
+// urls to iterate and are rejected; use Parse for them.
+func (sp *Parser) Iterator(r io.Reader) (*ItemIterator, error) {
+	ps, p, err := sp.start(r, nil)
+	if err != nil {
+		return nil, err
+	}
+	if matchElement(p, "sitemapindex", "") {
+		return nil, fmt.Errorf("Iterator does not support sitemap indexes")
+	}
+	ur, err := ps.newURLSetReader(p)
+	if err != nil {
+		return nil, err
+	}
+	return &ItemIterator{ur: ur}, nil
+}
+
+// Next returns the next url of the sitemap, and io.EOF once
+// all of them, or MaxItems of them, were returned.
+func (it *ItemIterator) Next() (*Item, error) {
+	if it.done {
+		return nil, io.EOF
+	}
+	if it.ur.sp.full(it.count) {
+		it.done = true
+		return nil, io.EOF
+	}
+
+	item, err := it.ur.next()
+	if err == io.EOF {
+		it.done = true
+		if err := it.ur.end(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+	if err != nil {
+		it.done = true
+		return nil, err
+	}
+	it.count++
+	return item, nil
+}
+
+// Close ends the iteration, later calls to Next return
+// io.EOF.  The reader passed to Iterator is not closed.
+func (it *ItemIterator) Close() {
+	it.done = true
+}
+
+func (sp *Parser) parse(feed io.Reader, emit func(*Item) error) (*Feed, error) {
+	ps, p, err := sp.start(feed, emit)
+	if err != nil {
+		return nil, err
+	}
+	return ps.parseRoot(p)
+}
+
+// start finds the root of feed, returning the pull parser
+// positioned on it and the copy of sp to parse it with.
+func (sp *Parser) start(feed io.Reader, emit func(*Item) error) (*Parser, *xpp.XMLPullParser, error) {
+	charsetReader := sp.CharsetReader
+	if charsetReader == nil {
+		charsetReader = shared.NewReaderLabel
+	}
+	p := xpp.NewXMLPullParser(feed, false, charsetReader)
+
+	_, err := shared.FindRoot(p)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Parse with a copy of the parser so the per-parse
+	// state doesn't leak between concurrent Parse calls.
+	ps := *sp
+	ps.warnings = nil
+	now := time.Now
+	if sp.Now != nil {
+		now = sp.Now
+	}
+	ps.now = now().UTC()
+	ps.emit = emit
+	return &ps, p, nil
+}
+
+func (sp *Parser) warn(format string, args ...interface{}) {
+	if sp.CollectWarnings {
+		sp.warnings = append(sp.warnings, fmt.Sprintf(format, args...))
+	}
+}
+
+// checkFutureDate warns about dates past the future date
+// tolerance and clamps them when ClampFutureDates is set.
+func (sp *Parser) checkFutureDate(date time.Time) time.Time {
+	if !date.After(sp.now.Add(sp.FutureDateTolerance)) {
+		return date
+	}
+	sp.warn("publication_date %s is in the future", date.Format(time.RFC3339))
+	if sp.ClampFutureDates {
+		return sp.now
+	}
+	return date
+}
+
+// full reports whether n urls reach MaxItems
+func (sp *Parser) full(n int) bool {
+	return sp.MaxItems > 0 && n >= sp.MaxItems
+}
+
+func (sp *Parser) checkLocLength(loc string) error {
+	n := utf8.RuneCountInString(loc)
+	if sp.MaxLocLength > 0 && n > sp.MaxLocLength {
+		return fmt.Errorf("loc of %d characters exceeds the maximum of %d", n, sp.MaxLocLength)
+	}
+	if n > locWarnLength {
+		sp.warn("loc of %d characters exceeds %d", n, locWarnLength)
+	}
+	return nil
 }
 
 func (sp *Parser) parseRoot(p *xpp.XMLPullParser) (*Feed, error) {
-	sitemapErr := p.Expect(xpp.StartTag, "urlset")
-	if sitemapErr != nil {
-		return nil, fmt.Errorf("%s", sitemapErr.Error())
+	if matchElement(p, "sitemapindex", "") {
+		return sp.parseIndex(p)
+	}
+
+	ur, err := sp.newURLSetReader(p)
+	if err != nil {
+		return nil, err
 	}
-	// Items found in feed root
-	// var channel *Feed
-	channel := &Feed{}
 	items := []*Item{}
 
-	ver := sp.parseVersion(p)
+	for {
+		item, err := ur.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if sp.emit != nil {
+			if err := sp.emit(item); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		items = append(items, item)
+		if sp.full(len(items)) {
+			break
+		}
+	}
+
+	// Nothing after the root end tag is read, so trailing
+	// content (stray bytes, a second document) is ignored.
+	if !sp.full(len(items)) {
+		if err := ur.end(); err != nil {
+			return nil, err
+		}
+	}
+
+	channel := &Feed{}
+	if len(items) > 0 {
+		channel.Items = items
+	}
+
+	// The feed title and language come from the
+	// first news publication in the sitemap.
+	if ur.news != nil {
+		channel.Title = ur.news.Title
+		channel.Language = ur.news.Language
+	}
+	// Otherwise fall back to the xml:lang of the urlset
+	if lang := ur.lang; channel.Language == "" && lang != "" {
+		if sp.NormalizeLanguage {
+			lang = shared.NormalizeLanguage(lang)
+		}
+		channel.Language = lang
+	}
+
+	if len(ur.extensions) > 0 {
+		channel.Extensions = ur.extensions
+	}
+
+	channel.Version = ur.version
+	channel.Warnings = sp.warnings
+	return channel, nil
+}
+
+// urlSetReader reads the urls of a urlset one at a time,
+// gathering the feed level data found along the way.
+type urlSetReader struct {
+	sp         *Parser
+	p          *xpp.XMLPullParser
+	extensions ext.Extensions
+	// The first news publication of the sitemap
+	news    *Feed
+	version string
+	lang    string
+}
+
+func (sp *Parser) newURLSetReader(p *xpp.XMLPullParser) (*urlSetReader, error) {
+	if err := p.Expect(xpp.StartTag, "urlset"); err != nil {
+		return nil, fmt.Errorf("%s", err.Error())
+	}
+	return &urlSetReader{
+		sp:         sp,
+		p:          p,
+		extensions: ext.Extensions{},
+		version:    sp.parseVersion(p),
+		lang:       p.Attribute("lang"),
+	}, nil
+}
 
+// next returns the next url of the urlset, or io.EOF once
+// its end tag is reached.
+func (ur *urlSetReader) next() (*Item, error) {
+	sp, p := ur.sp, ur.p
 	for {
 		tok, err := shared.NextTag(p)
 		if err != nil {
@@ -43,69 +506,169 @@ func (sp *Parser) parseRoot(p *xpp.XMLPullParser) (*Feed, error) {
 		}
 
 		if tok == xpp.EndTag {
-			break
+			return nil, io.EOF
 		}
 
 		if tok == xpp.StartTag {
-
-			// Skip any extensions found in the feed root.
-			if shared.IsExtension(p) {
+			if sp.skipExtension(p) {
 				p.Skip()
 				continue
 			}
 
-			name := strings.ToLower(p.Name)
+			// Keep feed level metadata such as generator
+			// info or build timestamps found in the root.
+			if isExtension(p) {
+				ext, err := shared.ParseExtension(ur.extensions, p)
+				if err != nil {
+					return nil, err
+				}
+				ur.extensions = ext
+				continue
+			}
 
-			if name == "url" {
+			if matchElement(p, "url", "") {
 				item, feed, err := sp.parseItem(p)
 				if err != nil {
 					return nil, err
 				}
-				items = append(items, item)
-				if channel == nil {
-					if feed.Title != "" {
-						channel.Title = feed.Title
-					} else {
-						channel.Title = "unkonow"
-					}
-					if feed.Language != "" {
-						channel.Language = feed.Language
-					} else {
-						channel.Language = "unknow"
-					}
+				if feed == nil && sp.NewsOnly {
+					continue
+				}
+				if ur.news == nil {
+					ur.news = feed
+				}
+				return item, nil
+			}
+
+			sp.warn("skipped unknown element <%s> in urlset", p.Name)
+			p.Skip()
+		}
+	}
+}
+
+// end checks that next stopped at the end tag of the urlset
+func (ur *urlSetReader) end() error {
+	if err := ur.p.Expect(xpp.EndTag, "urlset"); err != nil {
+		return fmt.Errorf("%s", err.Error())
+	}
+	return nil
+}
+
+func (sp *Parser) parseIndex(p *xpp.XMLPullParser) (*Feed, error) {
+	if err := p.Expect(xpp.StartTag, "sitemapindex"); err != nil {
+		return nil, err
+	}
+
+	index := &Feed{}
+	index.Items = []*Item{}
+	index.Version = sp.parseVersion(p)
+	refs := []*SitemapRef{}
+
+	for {
+		tok, err := shared.NextTag(p)
+		if err != nil {
+			return nil, err
+		}
+
+		if tok == xpp.EndTag {
+			break
+		}
+
+		if tok == xpp.StartTag {
+			if matchElement(p, "sitemap", "") {
+				ref, err := sp.parseSitemapRef(p)
+				if err != nil {
+					return nil, err
 				}
+				refs = append(refs, ref)
 			} else {
+				sp.warn("skipped unknown element <%s> in sitemapindex", p.Name)
 				p.Skip()
 			}
 		}
 	}
 
-	sitemapErr = p.Expect(xpp.EndTag, "urlset")
-	if sitemapErr != nil {
-		return nil, fmt.Errorf("%s", sitemapErr.Error())
+	if err := p.Expect(xpp.EndTag, "sitemapindex"); err != nil {
+		return nil, err
 	}
 
-	if channel == nil {
-		channel = &Feed{}
-		channel.Items = []*Item{}
+	if len(refs) > 0 {
+		index.Sitemaps = refs
 	}
 
-	if len(items) > 0 {
-		channel.Items = append(channel.Items, items...)
+	index.Warnings = sp.warnings
+	return index, nil
+}
+
+func (sp *Parser) parseSitemapRef(p *xpp.XMLPullParser) (ref *SitemapRef, err error) {
+	if err = p.Expect(xpp.StartTag, "sitemap"); err != nil {
+		return nil, err
 	}
 
-	channel.Version = ver
-	return channel, nil
+	ref = &SitemapRef{}
+	space := p.Space
+	for {
+		tok, err := shared.NextTag(p)
+		if err != nil {
+			return nil, err
+		}
+
+		if tok == xpp.EndTag {
+			break
+		}
+
+		if tok == xpp.StartTag {
+			if matchSitemapElement(p, "loc", space) {
+				result, err := shared.ParseText(p)
+				if err != nil {
+					return nil, err
+				}
+				ref.Link = strings.TrimSpace(result)
+				if err := sp.checkLocLength(ref.Link); err != nil {
+					return nil, err
+				}
+			} else if matchSitemapElement(p, "lastmod", space) {
+				result, err := shared.ParseText(p)
+				if err != nil {
+					return nil, err
+				}
+				ref.LastMod = result
+				date, err := parseLastMod(result)
+				if err == nil {
+					utcDate := date.UTC()
+					ref.LastModParsed = &utcDate
+				} else if strings.TrimSpace(result) != "" {
+					sp.warn("unparseable lastmod %q", result)
+				}
+			} else {
+				sp.warn("skipped unknown element <%s> in sitemap", p.Name)
+				p.Skip()
+			}
+		}
+	}
+
+	if err = p.Expect(xpp.EndTag, "sitemap"); err != nil {
+		return nil, err
+	}
+
+	return ref, nil
 }
 
 func (sp *Parser) parseVersion(p *xpp.XMLPullParser) (ver string) {
 	name := strings.ToLower(p.Name)
-	if name == "urlset" {
-		ns := p.Attribute("xmlns")
-		if ns == "http://www.sitemaps.org/schemas/sitemap/0.9" {
+	if name == "urlset" || name == "sitemapindex" {
+		// The root may declare the namespace under a prefix
+		// (sm:urlset), or under a prefix it doesn't use when the
+		// default namespace is another one, so check the namespace
+		// it resolved to and all of its declarations.
+		ver = "unknow"
+		if sameNamespace(p.Space, sitemapNS) {
 			ver = "0.9"
-		} else {
-			ver = "unknow"
+		}
+		for _, attr := range p.Attrs {
+			if isDeclaration(attr) && sameNamespace(attr.Value, sitemapNS) {
+				ver = "0.9"
+			}
 		}
 	} else {
 		ver = "unknow"
@@ -113,6 +676,8 @@ func (sp *Parser) parseVersion(p *xpp.XMLPullParser) (ver string) {
 	return
 }
 
+// parseItem parses a url entry.  The returned feed holds the
+// news publication of the entry and is nil if it had no news.
 func (sp *Parser) parseItem(p *xpp.XMLPullParser) (item *Item, feed *Feed, err error) {
 
 	if err = p.Expect(xpp.StartTag, "url"); err != nil {
@@ -120,8 +685,23 @@ func (sp *Parser) parseItem(p *xpp.XMLPullParser) (item *Item, feed *Feed, err e
 	}
 
 	item = &Item{}
-	feed = &Feed{}
-	extensions := ext.Extensions{}
+	space := p.Space
+	// Most urls have no extensions, so the map is only
+	// allocated once the first one is found.
+	var extensions ext.Extensions
+
+	// Keep the url attributes around for any vendor
+	// specific metadata that isn't otherwise modeled.
+	// Namespace declarations are markup, not metadata.
+	for _, attr := range p.Attrs {
+		if isDeclaration(attr) {
+			continue
+		}
+		if item.Attrs == nil {
+			item.Attrs = map[string]string{}
+		}
+		item.Attrs[attr.Name.Local] = attr.Value
+	}
 
 	for {
 		tok, err := shared.NextTag(p)
@@ -134,47 +714,139 @@ func (sp *Parser) parseItem(p *xpp.XMLPullParser) (item *Item, feed *Feed, err e
 		}
 
 		if tok == xpp.StartTag {
+			if sp.skipExtension(p) {
+				p.Skip()
+				continue
+			}
 
-			name := strings.ToLower(p.Name)
-
-			if shared.IsExtension(p) {
-				ext, err := shared.ParseExtension(extensions, p)
-				if err != nil {
-					return nil, nil, err
-				}
-				item.Extensions = ext
-			} else if name == "news" {
+			// The news, image, geo and xhtml:link elements live in
+			// their own namespaces, so they must be matched before
+			// the generic extension handling would capture them.
+			if matchElement(p, "news", newsNS) {
 				result, err := sp.parseNews(p)
 				//must change last code
 				if err != nil {
 					return nil, nil, err
 				}
 				item.Title = result.Title
+				item.Publication = result.Name
 				item.PubDate = result.PublicationDate
-				date, err := shared.ParseDate(result.PublicationDate)
+				date, err := parseDate(result.PublicationDate)
 				if err == nil {
-					utcDate := date.UTC()
+					utcDate := sp.checkFutureDate(date.UTC())
 					item.PubDateParsed = &utcDate
+				} else if result.PublicationDate != "" {
+					sp.warn("unparseable publication_date %q", result.PublicationDate)
 				}
-				feed.Title = result.Name
-				feed.Language = result.Language
-			} else if name == "loc" {
-				if len(item.Link) > 0 {
+				feed = &Feed{Title: result.Name, Language: result.Language}
+			} else if matchSitemapElement(p, "loc", space) {
+				// Only the first loc is kept, any others must still
+				// be consumed so the rest of the url is parsed.
+				if len(item.Link) > 0 && !sp.CaptureAllLocs {
+					p.Skip()
 					continue
 				}
 				result, err := shared.ParseText(p)
 				if err != nil {
 					return nil, nil, err
 				}
-				item.Link = result
-			} else if name == "image" {
+				// URLs can't contain whitespace, so always trim it
+				loc := strings.TrimSpace(result)
+				if err := sp.checkLocLength(loc); err != nil {
+					return nil, nil, err
+				}
+				if item.Link == "" {
+					item.Link = loc
+				}
+				if sp.CaptureAllLocs && loc != "" {
+					item.Locs = append(item.Locs, loc)
+				}
+			} else if matchSitemapElement(p, "lastmod", space) {
+				result, err := shared.ParseText(p)
+				if err != nil {
+					return nil, nil, err
+				}
+				item.LastMod = result
+				date, err := parseLastMod(result)
+				if err == nil {
+					utcDate := date.UTC()
+					item.LastModParsed = &utcDate
+				} else if strings.TrimSpace(result) != "" {
+					sp.warn("unparseable lastmod %q", result)
+				}
+			} else if matchSitemapElement(p, "changefreq", space) {
+				result, err := shared.ParseText(p)
+				if err != nil {
+					return nil, nil, err
+				}
+				item.ChangeFreq = result
+				if !changeFreqs[strings.ToLower(result)] {
+					sp.warn("unknown changefreq %q", result)
+				}
+			} else if matchSitemapElement(p, "priority", space) {
+				result, err := shared.ParseText(p)
+				if err != nil {
+					return nil, nil, err
+				}
+				item.Priority = result
+				priority, err := strconv.ParseFloat(result, 64)
+				if err != nil || priority < 0 || priority > 1 {
+					sp.warn("priority %q is not between 0.0 and 1.0", result)
+				}
+			} else if matchElement(p, "image", imageNS) {
 				result, err := sp.parseImage(p)
 				if err != nil {
 					return nil, nil, err
 				}
-				item.Image = result
+				if item.Image == nil {
+					item.Image = result
+				}
+				item.Images = append(item.Images, result)
+			} else if matchElement(p, "video", videoNS) {
+				// Videos were kept as generic extensions before they
+				// were modeled, so they still are.
+				video, err := shared.ParseExtension(ext.Extensions{}, p)
+				if err != nil {
+					return nil, nil, err
+				}
+				if extensions == nil {
+					extensions = ext.Extensions{}
+				}
+				for prefix, elements := range video {
+					if extensions[prefix] == nil {
+						extensions[prefix] = map[string][]ext.Extension{}
+					}
+					for name, e := range elements {
+						extensions[prefix][name] = append(extensions[prefix][name], e...)
+						item.Videos = append(item.Videos, newVideo(e[0]))
+					}
+				}
+			} else if matchElement(p, "geo", geoNS) {
+				result, err := sp.parseGeo(p)
+				if err != nil {
+					return nil, nil, err
+				}
+				item.Geo = result
+			} else if matchElement(p, "link", xhtmlNS) {
+				result, err := sp.parseAlternate(p)
+				if err != nil {
+					return nil, nil, err
+				}
+				if result != nil {
+					item.Alternates = append(item.Alternates, result)
+				}
+			} else if isExtension(p) {
+				if extensions == nil {
+					extensions = ext.Extensions{}
+				}
+				ext, err := shared.ParseExtension(extensions, p)
+				if err != nil {
+					return nil, nil, err
+				}
+				extensions = ext
 			} else {
 				// Skip any elements not part of the item spec
+				sp.warn("skipped unknown element <%s> in url", p.Name)
 				p.Skip()
 			}
 		}
@@ -208,9 +880,7 @@ func (sp *Parser) parseNews(p *xpp.XMLPullParser) (news *News, err error) {
 		}
 
 		if tok == xpp.StartTag {
-			name := strings.ToLower(p.Name)
-
-			if name == "publication" {
+			if matchElement(p, "publication", "") {
 				//newsname for feed
 				result, err := sp.parsePublication(p)
 				if err != nil {
@@ -218,19 +888,22 @@ func (sp *Parser) parseNews(p *xpp.XMLPullParser) (news *News, err error) {
 				}
 				news.Name = result.Name
 				news.Language = result.Language
-			} else if name == "publication_date" {
+			} else if matchElement(p, "publication_date", "") {
 				//time for link
 				result, err := shared.ParseText(p)
 				if err != nil {
 					return nil, err
 				}
 				news.PublicationDate = result
-			} else if name == "title" {
+			} else if matchElement(p, "title", "") {
 				//title for link
 				result, err := shared.ParseText(p)
 				if err != nil {
 					return nil, err
 				}
+				if sp.StripHTML {
+					result = shared.StripHTML(result)
+				}
 				news.Title = result
 			} else {
 				p.Skip()
@@ -261,14 +934,15 @@ func (sp *Parser) parseImage(p *xpp.XMLPullParser) (image *Image, err error) {
 		}
 
 		if tok == xpp.StartTag {
-			name := strings.ToLower(p.Name)
-
-			if name == "loc" {
+			if matchElement(p, "loc", "") {
 				result, err := shared.ParseText(p)
 				if err != nil {
 					return nil, err
 				}
-				image.Link = result
+				image.Link = strings.TrimSpace(result)
+				if err := sp.checkLocLength(image.Link); err != nil {
+					return nil, err
+				}
 			} else {
 				p.Skip()
 			}
@@ -282,6 +956,95 @@ func (sp *Parser) parseImage(p *xpp.XMLPullParser) (image *Image, err error) {
 	return image, nil
 }
 
+// newVideo models a video:video element parsed as an extension
+func newVideo(e ext.Extension) *Video {
+	text := func(name string) string {
+		if children := e.Children[name]; len(children) > 0 {
+			return strings.TrimSpace(children[0].Value)
+		}
+		return ""
+	}
+
+	video := &Video{
+		ThumbnailLoc: text("thumbnail_loc"),
+		Title:        text("title"),
+		Description:  text("description"),
+		ContentLoc:   text("content_loc"),
+		PlayerLoc:    text("player_loc"),
+		Duration:     text("duration"),
+	}
+	if children := e.Children["restriction"]; len(children) > 0 {
+		video.Restriction = &VideoRestriction{
+			Relationship: children[0].Attrs["relationship"],
+			Countries:    strings.Fields(children[0].Value),
+		}
+	}
+	if children := e.Children["platform"]; len(children) > 0 {
+		video.Platform = &VideoPlatform{
+			Relationship: children[0].Attrs["relationship"],
+			Platforms:    strings.Fields(children[0].Value),
+		}
+	}
+	return video
+}
+
+func (sp *Parser) parseGeo(p *xpp.XMLPullParser) (geo *GeoExtension, err error) {
+	if err = p.Expect(xpp.StartTag, "geo"); err != nil {
+		return nil, err
+	}
+	geo = &GeoExtension{}
+	for {
+		tok, err := shared.NextTag(p)
+		if err != nil {
+			return geo, err
+		}
+
+		if tok == xpp.EndTag {
+			break
+		}
+
+		if tok == xpp.StartTag {
+			if matchElement(p, "format", "") {
+				result, err := shared.ParseText(p)
+				if err != nil {
+					return nil, err
+				}
+				geo.Format = result
+			} else {
+				p.Skip()
+			}
+		}
+	}
+
+	if err = p.Expect(xpp.EndTag, "geo"); err != nil {
+		return nil, err
+	}
+
+	return geo, nil
+}
+
+func (sp *Parser) parseAlternate(p *xpp.XMLPullParser) (alt *Alternate, err error) {
+	if err = p.Expect(xpp.StartTag, "link"); err != nil {
+		return nil, err
+	}
+
+	rel := p.Attribute("rel")
+	href := strings.TrimSpace(p.Attribute("href"))
+	lang := strings.TrimSpace(p.Attribute("hreflang"))
+
+	// xhtml:link carries its data in attributes so
+	// the content (if any) can be skipped.
+	if err = p.Skip(); err != nil {
+		return nil, err
+	}
+
+	if !strings.EqualFold(rel, "alternate") || href == "" {
+		return nil, nil
+	}
+
+	return &Alternate{Link: href, Lang: lang}, nil
+}
+
 func (sp *Parser) parsePublication(p *xpp.XMLPullParser) (news *News, err error) {
 	if err = p.Expect(xpp.StartTag, "publication"); err != nil {
 		return nil, err
@@ -299,21 +1062,22 @@ func (sp *Parser) parsePublication(p *xpp.XMLPullParser) (news *News, err error)
 		}
 
 		if tok == xpp.StartTag {
-			name := strings.ToLower(p.Name)
-
-			if name == "name" {
+			if matchElement(p, "name", "") {
 				//newsname for feed
 				result, err := shared.ParseText(p)
 				if err != nil {
 					return nil, err
 				}
 				news.Name = result
-			} else if name == "language" {
+			} else if matchElement(p, "language", "") {
 				//time for link
 				result, err := shared.ParseText(p)
 				if err != nil {
 					return nil, err
 				}
+				if sp.NormalizeLanguage {
+					result = shared.NormalizeLanguage(result)
+				}
 				news.Language = result
 			} else {
 				p.Skip()
@@ -327,3 +1091,157 @@ func (sp *Parser) parsePublication(p *xpp.XMLPullParser) (news *News, err error)
 
 	return news, nil
 }
+
+// matchElement reports whether the current element has the given
+// local name, compared case-insensitively so mixed-case prefixes
+// and names still match.  When nsURI is non-empty the element must
+// also belong to that namespace, unless its prefix was never
+// declared and so can't be resolved to a namespace at all.
+func matchElement(p *xpp.XMLPullParser, localName string, nsURI string) bool {
+	if !strings.EqualFold(p.Name, localName) {
+		return false
+	}
+
+	if nsURI == "" {
+		return true
+	}
+
+	space := strings.TrimSpace(p.Space)
+	if sameNamespace(space, nsURI) {
+		return true
+	}
+
+	_, declared := p.Spaces[space]
+	return !declared
+}
+
+// matchSitemapElement is matchElement for the elements of the
+// sitemaps.org schema, which must be in that namespace, in no
+// namespace, or in the one of their parent url or sitemap for
+// files declaring another namespace as the default.
+func matchSitemapElement(p *xpp.XMLPullParser, localName string, parentSpace string) bool {
+	if matchElement(p, localName, sitemapNS) {
+		return true
+	}
+	return strings.EqualFold(p.Name, localName) && sameNamespace(p.Space, parentSpace)
+}
+
+// isExtension reports whether the current element is an
+// extension, i.e. in a namespace other than the sitemaps.org
+// one, which may be bound to a prefix.
+func isExtension(p *xpp.XMLPullParser) bool {
+	return shared.IsExtension(p) && !sameNamespace(p.Space, sitemapNS)
+}
+
+// skipExtension reports whether the current element is an
+// extension that ParseExtensions or SkipExtensions exclude.
+func (sp *Parser) skipExtension(p *xpp.XMLPullParser) bool {
+	if len(sp.ParseExtensions) == 0 && len(sp.SkipExtensions) == 0 {
+		return false
+	}
+	if !isExtension(p) {
+		return false
+	}
+
+	space := strings.TrimSpace(p.Space)
+	for _, ns := range sp.SkipExtensions {
+		if sameNamespace(space, ns) {
+			return true
+		}
+	}
+	if len(sp.ParseExtensions) == 0 {
+		return false
+	}
+	for _, ns := range sp.ParseExtensions {
+		if sameNamespace(space, ns) {
+			return false
+		}
+	}
+	return true
+}
+
+// isDeclaration reports whether attr declares a namespace,
+// either the default one (xmlns) or a prefix (xmlns:image).
+func isDeclaration(attr xml.Attr) bool {
+	return attr.Name.Space == "xmlns" || attr.Name.Space == "" && attr.Name.Local == "xmlns"
+}
+
+// sameNamespace compares two namespace uris ignoring case,
+// the http/https scheme and a trailing slash, since generators
+// commonly get those wrong.
+func sameNamespace(a, b string) bool {
+	return normalizeNamespace(a) == normalizeNamespace(b)
+}
+
+func normalizeNamespace(ns string) string {
+	ns = strings.ToLower(strings.TrimSpace(ns))
+	if strings.HasPrefix(ns, "https://") {
+		ns = strings.TrimPrefix(ns, "https://")
+	} else {
+		ns = strings.TrimPrefix(ns, "http://")
+	}
+	return strings.TrimSuffix(ns, "/")
+}
+
+// w3cDateFormats are the W3C Datetime layouts required by
+// the sitemaps.org protocol for lastmod and news dates.
+var w3cDateFormats = []string{
+	"2006-01-02",
+	"2006",
+	"2006-01",
+	time.RFC3339,
+	time.RFC3339Nano,
+	"2006-01-02T15:04Z07:00",
+}
+
+// parseDate tries the W3C Datetime layouts used by nearly
+// every sitemap before the much longer list of feed date
+// layouts, which is slow to fall through for these dates.
+func parseDate(ds string) (time.Time, error) {
+	d := strings.TrimSpace(ds)
+	for _, f := range w3cDateFormats {
+		if t, err := time.Parse(f, d); err == nil {
+			return t, nil
+		}
+	}
+	return shared.ParseDate(ds)
+}
+
+// compactDateFormats are the digit only dates some generators
+// emit as lastmod
+var compactDateFormats = []string{
+	"20060102",
+	"200601021504",
+	"20060102150405",
+}
+
+// parseLastMod parses a lastmod, which some generators emit as
+// a unix timestamp, in seconds when it has 9 or 10 digits or in
+// milliseconds when it has 13, or as a compact date such as
+// 20060102, in place of a W3C Datetime.  Other digit only values,
+// such as a four digit W3C year, are parsed as dates.
+func parseLastMod(ds string) (time.Time, error) {
+	d := strings.TrimSpace(ds)
+	if d == "" || strings.TrimLeft(d, "0123456789") != "" {
+		return parseDate(ds)
+	}
+
+	switch len(d) {
+	case 9, 10, 13:
+		n, err := strconv.ParseInt(d, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if len(d) == 13 {
+			return time.Unix(0, n*int64(time.Millisecond)), nil
+		}
+		return time.Unix(n, 0), nil
+	}
+
+	for _, f := range compactDateFormats {
+		if t, err := time.Parse(f, d); err == nil {
+			return t, nil
+		}
+	}
+	return parseDate(ds)
+}
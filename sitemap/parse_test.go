@@ -2,19 +2,23 @@ package sitemap_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/shuyaoyimei/gofeed/sitemap"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestParser_Parse(t *testing.T) {
-	files, _ := filepath.Glob("../testdata/parser/rss/*.xml")
+	files, _ := filepath.Glob("../testdata/parser/sitemap/*.xml")
 	for _, f := range files {
 		base := filepath.Base(f)
 		name := strings.TrimSuffix(base, filepath.Ext(base))
@@ -22,7 +26,7 @@ func TestParser_Parse(t *testing.T) {
 		fmt.Printf("Testing %s... ", name)
 
 		// Get actual source feed
-		ff := fmt.Sprintf("../testdata/parser/rss/%s.xml", name)
+		ff := fmt.Sprintf("../testdata/parser/sitemap/%s.xml", name)
 		f, _ := ioutil.ReadFile(ff)
 
 		// Parse actual feed
@@ -30,7 +34,7 @@ func TestParser_Parse(t *testing.T) {
 		actual, _ := fp.Parse(bytes.NewReader(f))
 
 		// Get json encoded expected feed result
-		ef := fmt.Sprintf("../testdata/parser/rss/%s.json", name)
+		ef := fmt.Sprintf("../testdata/parser/sitemap/%s.json", name)
 		e, _ := ioutil.ReadFile(ef)
 
 		// Unmarshal expected feed
@@ -45,4 +49,1054 @@ func TestParser_Parse(t *testing.T) {
 	}
 }
 
+func TestParser_ParseGeo(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_geo.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+
+	assert.Nil(t, err)
+	assert.Len(t, feed.Items, 2)
+	assert.Equal(t, "http://www.example.com/download?format=kml", feed.Items[0].Link)
+	assert.Equal(t, &sitemap.GeoExtension{Format: "kml"}, feed.Items[0].Geo)
+	assert.Nil(t, feed.Items[0].Extensions)
+	assert.Nil(t, feed.Items[1].Geo)
+}
+
+func TestParser_ParseWarnings(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_warnings.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Len(t, feed.Items, 3)
+	assert.Nil(t, feed.Warnings)
+
+	fp = &sitemap.Parser{CollectWarnings: true}
+	feed, err = fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Len(t, feed.Items, 3)
+	assert.Equal(t, "monthly", feed.Items[0].ChangeFreq)
+	assert.Equal(t, "0.8", feed.Items[0].Priority)
+	assert.NotNil(t, feed.Items[0].LastModParsed)
+	assert.Equal(t, "yesterday", feed.Items[1].LastMod)
+	assert.Nil(t, feed.Items[1].LastModParsed)
+	// An empty lastmod isn't worth a warning
+	assert.Nil(t, feed.Items[2].LastModParsed)
+	assert.Equal(t, []string{
+		"skipped unknown element <generator> in urlset",
+		`unparseable lastmod "yesterday"`,
+		`unknown changefreq "sometimes"`,
+		`priority "1.5" is not between 0.0 and 1.0`,
+		"skipped unknown element <rating> in url",
+	}, feed.Warnings)
+}
+
+func TestParser_ParseChildOrder(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_child_order.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Len(t, feed.Items, 2)
+
+	first := feed.Items[0]
+	assert.Equal(t, "http://www.example.com/first", first.Link)
+	assert.Equal(t, "2016-11-21", first.LastMod)
+	assert.Equal(t, &sitemap.Image{Link: "http://www.example.com/first.jpg"}, first.Image)
+
+	second := feed.Items[1]
+	assert.Equal(t, "http://www.example.com/second", second.Link)
+	assert.Equal(t, "Second Article", second.Title)
+	assert.NotNil(t, second.PubDateParsed)
+	assert.Equal(t, "daily", second.ChangeFreq)
+	assert.Equal(t, "0.5", second.Priority)
+}
+
+func TestParser_ParseURLAttrs(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_url_attrs.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Len(t, feed.Items, 2)
+	assert.Equal(t, map[string]string{"data-section": "news", "data-id": "42"}, feed.Items[0].Attrs)
+	assert.Nil(t, feed.Items[1].Attrs)
+}
+
+func TestParser_ParseMixedCase(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_mixed_case.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Len(t, feed.Items, 2)
+
+	// Declared mixed-case prefix
+	first := feed.Items[0]
+	assert.Equal(t, "http://www.example.com/first", first.Link)
+	assert.Equal(t, "First Article", first.Title)
+
+	// An image element from a foreign namespace is an extension
+	assert.Nil(t, first.Image)
+	assert.Len(t, first.Extensions["thumb"]["image"], 1)
+
+	// Undeclared prefixes still match on the local name
+	second := feed.Items[1]
+	assert.Equal(t, &sitemap.Image{Link: "http://www.example.com/second.jpg"}, second.Image)
+}
+
+func TestParser_ParseExtensionCase(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_mixed_case_extensions.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+
+	// Extensions keep the casing of their element and
+	// attribute names
+	info := feed.Extensions["gen"]["GeneratorInfo"]
+	if assert.Len(t, info, 1) {
+		assert.Equal(t, "GeneratorInfo", info[0].Name)
+		assert.Equal(t, "2.1", info[0].Attrs["Version"])
+		assert.Len(t, info[0].Children["BuildTime"], 1)
+	}
+	if assert.Len(t, feed.Items, 1) {
+		item := feed.Items[0]
+		pageType := item.Extensions["gen"]["PageType"]
+		if assert.Len(t, pageType, 1) {
+			assert.Equal(t, "Article", pageType[0].Value)
+			assert.Equal(t, "longForm", pageType[0].Attrs["Template"])
+		}
+		assert.Nil(t, item.Extensions["gen"]["pagetype"])
+
+		// while the modeled elements match in any case
+		assert.Equal(t, "http://www.example.com/article", item.Link)
+		assert.Equal(t, "2017-06-01", item.LastMod)
+		assert.Equal(t, "Article", item.Title)
+	}
+}
+
+func TestParser_ParseIndex(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_index.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Equal(t, "0.9", feed.Version)
+	assert.Len(t, feed.Items, 0)
+	assert.Len(t, feed.Sitemaps, 3)
+
+	first := time.Date(2004, 10, 1, 18, 23, 17, 0, time.UTC)
+	assert.Equal(t, "http://www.example.com/sitemap1.xml.gz", feed.Sitemaps[0].Link)
+	assert.Equal(t, "2004-10-01T18:23:17+00:00", feed.Sitemaps[0].LastMod)
+	assert.Equal(t, &first, feed.Sitemaps[0].LastModParsed)
+
+	second := time.Date(2005, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, &second, feed.Sitemaps[1].LastModParsed)
+
+	assert.Equal(t, "", feed.Sitemaps[2].LastMod)
+	assert.Nil(t, feed.Sitemaps[2].LastModParsed)
+}
+
+func TestParser_ParseAlternates(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_alternates.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Len(t, feed.Items, 2)
+	assert.Len(t, feed.Items[0].Alternates, 3)
+	assert.Equal(t, &sitemap.Alternate{Link: "http://www.example.com/deutsch/page.html", Lang: "de"}, feed.Items[0].Alternates[0])
+	assert.Nil(t, feed.Items[0].Extensions)
+	assert.Nil(t, feed.Items[1].Alternates)
+
+	link := "http://www.example.com/english/page.html"
+	assert.Equal(t, "http://www.example.com/schweiz-deutsch/page.html", feed.AlternateFor(link, "DE-CH"))
+	assert.Equal(t, link, feed.AlternateFor(link, "en"))
+	assert.Equal(t, "", feed.AlternateFor(link, "fr"))
+	assert.Equal(t, "", feed.AlternateFor("http://www.example.com/about", "de"))
+}
+
+func TestParser_ParseTrailingGarbage(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_trailing_garbage.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Len(t, feed.Items, 1)
+	assert.Equal(t, "http://www.example.com/", feed.Items[0].Link)
+}
+
+func TestParser_ParseNewsOnly(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_news_mixed.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Len(t, feed.Items, 4)
+	assert.Equal(t, "The Example Times", feed.Title)
+	assert.Equal(t, "en", feed.Language)
+
+	fp = &sitemap.Parser{NewsOnly: true}
+	feed, err = fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Len(t, feed.Items, 2)
+	assert.Equal(t, "http://www.example.org/business/article55.html", feed.Items[0].Link)
+	assert.Equal(t, "Companies A, B in Merger Talks", feed.Items[0].Title)
+	assert.Equal(t, "http://www.example.org/business/article56.html", feed.Items[1].Link)
+	assert.Equal(t, "The Example Times", feed.Title)
+	assert.Equal(t, "en", feed.Language)
+}
+
+func TestParser_ParseVersion(t *testing.T) {
+	var versionTests = []struct {
+		file    string
+		version string
+	}{
+		{"sitemap_trailing_garbage.xml", "0.9"},
+		{"sitemap_ns_https.xml", "0.9"},
+		{"sitemap_ns_trailing_slash.xml", "0.9"},
+		{"sitemap_ns_uppercase.xml", "0.9"},
+		{"sitemap_default_news_ns.xml", "0.9"},
+	}
+
+	for _, test := range versionTests {
+		path := fmt.Sprintf("../testdata/parser/sitemap/%s", test.file)
+		f, _ := ioutil.ReadFile(path)
+
+		fp := &sitemap.Parser{}
+		feed, err := fp.Parse(bytes.NewReader(f))
+		assert.Nil(t, err)
+		assert.Equal(t, test.version, feed.Version, "Feed file %s did not have version %s", test.file, test.version)
+		assert.Len(t, feed.Items, 1)
+	}
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(strings.NewReader(`<urlset xmlns="http://example.com/not-a-sitemap"></urlset>`))
+	assert.Nil(t, err)
+	assert.Equal(t, "unknow", feed.Version)
+}
+
+func TestParser_ParseHeader(t *testing.T) {
+	var headerTests = []struct {
+		file       string
+		root       string
+		language   string
+		namespaces map[string]string
+	}{
+		{"sitemap_index.xml", "sitemapindex", "", map[string]string{
+			"": "http://www.sitemaps.org/schemas/sitemap/0.9",
+		}},
+		{"sitemap_xml_lang.xml", "urlset", "de_ch", map[string]string{
+			"": "http://www.sitemaps.org/schemas/sitemap/0.9",
+		}},
+		{"sitemap_extension_namespaces.xml", "urlset", "", map[string]string{
+			"":      "http://www.sitemaps.org/schemas/sitemap/0.9",
+			"news":  "http://www.google.com/schemas/sitemap-news/0.9",
+			"image": "http://www.google.com/schemas/sitemap-image/1.1",
+			"video": "http://www.google.com/schemas/sitemap-video/1.1",
+		}},
+	}
+
+	for _, test := range headerTests {
+		path := fmt.Sprintf("../testdata/parser/sitemap/%s", test.file)
+		f, _ := ioutil.ReadFile(path)
+
+		fp := &sitemap.Parser{}
+		feed, err := fp.ParseHeader(bytes.NewReader(f))
+		assert.Nil(t, err, test.file)
+		assert.Equal(t, test.root, feed.Root, test.file)
+		assert.Equal(t, "0.9", feed.Version, test.file)
+		assert.Equal(t, test.language, feed.Language, test.file)
+		for prefix, ns := range test.namespaces {
+			assert.Equal(t, ns, feed.Namespaces[prefix], "%s: xmlns:%s", test.file, prefix)
+		}
+		assert.Nil(t, feed.Items, test.file)
+		assert.Nil(t, feed.Sitemaps, test.file)
+	}
+
+	// Nothing past the root start tag is read
+	fp := &sitemap.Parser{}
+	feed, err := fp.ParseHeader(strings.NewReader(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>http://www.example.org/</lo`))
+	assert.Nil(t, err)
+	assert.Equal(t, "urlset", feed.Root)
+
+	_, err = fp.ParseHeader(strings.NewReader(`<rss version="2.0"><channel></channel></rss>`))
+	assert.NotNil(t, err)
+}
+
+func TestParser_ParseNewsDates(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_news_dates.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+
+	var dateTests = []struct {
+		link   string
+		parsed string
+	}{
+		{"http://www.example.org/rfc3339", "2008-12-22T23:00:00Z"},
+		{"http://www.example.org/date", "2008-12-23T00:00:00Z"},
+		{"http://www.example.org/rfc1123", "2008-12-23T00:00:00Z"},
+		{"http://www.example.org/rfc1123z", "2008-12-23T05:00:00Z"},
+		{"http://www.example.org/rfc822", "2008-12-23T05:00:00Z"},
+		{"http://www.example.org/rfc822z", "2008-12-22T23:00:00Z"},
+		{"http://www.example.org/invalid", ""},
+	}
+
+	if assert.Len(t, feed.Items, len(dateTests)) {
+		for i, test := range dateTests {
+			item := feed.Items[i]
+			assert.Equal(t, test.link, item.Link)
+			assert.NotEmpty(t, item.PubDate)
+			if test.parsed == "" {
+				assert.Nil(t, item.PubDateParsed, "%s should not have parsed", item.PubDate)
+			} else if assert.NotNil(t, item.PubDateParsed, "%s did not parse", item.PubDate) {
+				assert.Equal(t, test.parsed, item.PubDateParsed.Format(time.RFC3339))
+			}
+		}
+	}
+}
+
+func TestParser_ParseLastModEpoch(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_lastmod_epoch.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+
+	var dateTests = []struct {
+		lastMod string
+		parsed  string
+	}{
+		{"1609459200", "2021-01-01T00:00:00Z"},
+		{"1609459200500", "2021-01-01T00:00:00.5Z"},
+		{"2021-01-01T00:00:00+00:00", "2021-01-01T00:00:00Z"},
+		{"20210101", "2021-01-01T00:00:00Z"},
+		{"202101011200", "2021-01-01T12:00:00Z"},
+		{"2021", "2021-01-01T00:00:00Z"},
+		{"2021-03", "2021-03-01T00:00:00Z"},
+		{"16094592OO", ""},
+	}
+
+	if assert.Len(t, feed.Items, len(dateTests)) {
+		for i, test := range dateTests {
+			item := feed.Items[i]
+			assert.Equal(t, test.lastMod, item.LastMod)
+			if test.parsed == "" {
+				assert.Nil(t, item.LastModParsed, "%s should not have parsed", item.LastMod)
+			} else if assert.NotNil(t, item.LastModParsed, "%s did not parse", item.LastMod) {
+				assert.Equal(t, test.parsed, item.LastModParsed.Format(time.RFC3339Nano))
+			}
+		}
+	}
+}
+
+func TestFeed_GroupByAlternateSet(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_alternate_sets.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+
+	var groups [][]string
+	for _, group := range feed.GroupByAlternateSet() {
+		var links []string
+		for _, item := range group {
+			links = append(links, item.Link)
+		}
+		groups = append(groups, links)
+	}
+
+	assert.Equal(t, [][]string{
+		{"http://www.example.com/en/home", "http://www.example.com/de/home", "http://www.example.com/fr/home"},
+		{"http://www.example.com/en/about", "http://www.example.com/de/about"},
+		{"http://www.example.com/contact"},
+	}, groups)
+}
+
+func TestItem_AllLinks(t *testing.T) {
+	item := &sitemap.Item{
+		Link:  "http://www.example.com/en/page",
+		Image: &sitemap.Image{Link: "http://www.example.com/image.jpg"},
+		Images: []*sitemap.Image{
+			{Link: "http://www.example.com/image.jpg"},
+			{Link: "http://www.example.com/image2.jpg"},
+		},
+		Videos: []*sitemap.Video{
+			{ContentLoc: "http://www.example.com/video.mp4", PlayerLoc: "http://www.example.com/player?v=1"},
+		},
+		Alternates: []*sitemap.Alternate{
+			{Link: "http://www.example.com/en/page", Lang: "en"},
+			{Link: "http://www.example.com/de/seite", Lang: "de"},
+			{Link: "", Lang: "fr"},
+		},
+	}
+	assert.Equal(t, []string{
+		"http://www.example.com/en/page",
+		"http://www.example.com/image.jpg",
+		"http://www.example.com/image2.jpg",
+		"http://www.example.com/video.mp4",
+		"http://www.example.com/player?v=1",
+		"http://www.example.com/de/seite",
+	}, item.AllLinks())
+
+	assert.Equal(t, []string{}, (&sitemap.Item{}).AllLinks())
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(strings.NewReader(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">
+<url><loc>http://www.example.com/gallery</loc>
+<image:image><image:loc>http://www.example.com/a.jpg</image:loc></image:image>
+<image:image><image:loc>http://www.example.com/b.jpg</image:loc></image:image>
+</url></urlset>`))
+	assert.Nil(t, err)
+	assert.Equal(t, "http://www.example.com/a.jpg", feed.Items[0].Image.Link)
+	assert.Equal(t, []string{
+		"http://www.example.com/gallery",
+		"http://www.example.com/a.jpg",
+		"http://www.example.com/b.jpg",
+	}, feed.Items[0].AllLinks())
+}
+
+func TestItem_NextCrawlAfter(t *testing.T) {
+	lastMod := time.Date(2017, 1, 31, 12, 0, 0, 0, time.UTC)
+
+	var crawlTests = []struct {
+		changeFreq string
+		lastMod    *time.Time
+		expected   time.Time
+	}{
+		{"always", &lastMod, lastMod},
+		{"hourly", &lastMod, time.Date(2017, 1, 31, 13, 0, 0, 0, time.UTC)},
+		{"daily", &lastMod, time.Date(2017, 2, 1, 12, 0, 0, 0, time.UTC)},
+		{" Weekly ", &lastMod, time.Date(2017, 2, 7, 12, 0, 0, 0, time.UTC)},
+		{"monthly", &lastMod, time.Date(2017, 3, 3, 12, 0, 0, 0, time.UTC)},
+		{"yearly", &lastMod, time.Date(2018, 1, 31, 12, 0, 0, 0, time.UTC)},
+		{"never", &lastMod, time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{"never", nil, time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{"daily", nil, time.Time{}},
+		{"", &lastMod, time.Time{}},
+		{"fortnightly", &lastMod, time.Time{}},
+	}
+
+	for _, test := range crawlTests {
+		item := &sitemap.Item{ChangeFreq: test.changeFreq, LastModParsed: test.lastMod}
+		assert.Equal(t, test.expected, item.NextCrawlAfter(), "changefreq %q", test.changeFreq)
+	}
+}
+
+func TestParser_ParseLocWhitespace(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_whitespace.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Len(t, feed.Items, 2)
+	assert.Equal(t, "http://www.example.com/", feed.Items[0].Link)
+	assert.Equal(t, "http://www.example.com/cdata", feed.Items[1].Link)
+}
+
+func TestParser_ParseCaptureAllLocs(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_multiple_locs.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	if assert.Len(t, feed.Items, 3) {
+		assert.Equal(t, "http://www.example.com/article", feed.Items[0].Link)
+		assert.Equal(t, "2020-01-01", feed.Items[0].LastMod)
+		assert.Equal(t, "daily", feed.Items[1].ChangeFreq)
+		for _, item := range feed.Items {
+			assert.Nil(t, item.Locs)
+		}
+	}
+
+	fp.CaptureAllLocs = true
+	feed, err = fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	if assert.Len(t, feed.Items, 3) {
+		assert.Equal(t, "http://www.example.com/article", feed.Items[0].Link)
+		assert.Equal(t, []string{"http://www.example.com/article", "http://www.example.com/amp/article"}, feed.Items[0].Locs)
+		assert.Equal(t, "2020-01-01", feed.Items[0].LastMod)
+		assert.Equal(t, "http://www.example.com/story", feed.Items[1].Link)
+		assert.Equal(t, []string{"http://www.example.com/story", "http://www.example.com/story?canonical=1"}, feed.Items[1].Locs)
+		assert.Equal(t, "daily", feed.Items[1].ChangeFreq)
+		assert.Equal(t, []string{"http://www.example.com/single"}, feed.Items[2].Locs)
+		assert.Equal(t, []string{"http://www.example.com/article", "http://www.example.com/amp/article"}, feed.Items[0].AllLinks())
+	}
+}
+
+func TestParser_ParseFeedExtensions(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_feed_extensions.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Len(t, feed.Items, 1)
+
+	generator := feed.Extensions["gen"]["generator"]
+	if assert.Len(t, generator, 1) {
+		assert.Equal(t, "Example Sitemap Builder", generator[0].Value)
+		assert.Equal(t, "2.1", generator[0].Attrs["version"])
+	}
+	built := feed.Extensions["gen"]["built"]
+	if assert.Len(t, built, 1) {
+		assert.Equal(t, "2017-06-01T12:00:00Z", built[0].Value)
+	}
+}
+
+func TestParser_ParseVideo(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_video.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	if !assert.Len(t, feed.Items, 1) || !assert.Len(t, feed.Items[0].Videos, 2) {
+		return
+	}
+
+	video := feed.Items[0].Videos[0]
+	assert.Equal(t, "http://www.example.com/thumbs/123.jpg", video.ThumbnailLoc)
+	assert.Equal(t, "Grilling steaks for summer", video.Title)
+	assert.Equal(t, "http://streamserver.example.com/video123.mp4", video.ContentLoc)
+	assert.Equal(t, "http://www.example.com/videoplayer.php?video=123", video.PlayerLoc)
+	assert.Equal(t, "600", video.Duration)
+	if assert.NotNil(t, video.Restriction) {
+		assert.Equal(t, "allow", video.Restriction.Relationship)
+		assert.Equal(t, []string{"IE", "GB", "US", "CA"}, video.Restriction.Countries)
+	}
+	if assert.NotNil(t, video.Platform) {
+		assert.Equal(t, "deny", video.Platform.Relationship)
+		assert.Equal(t, []string{"tv"}, video.Platform.Platforms)
+	}
+
+	video = feed.Items[0].Videos[1]
+	assert.Equal(t, "Steak sides", video.Title)
+	assert.Nil(t, video.Restriction)
+	assert.Nil(t, video.Platform)
+
+	// The videos are still available as extensions
+	assert.Len(t, feed.Items[0].Extensions["video"]["video"], 2)
+}
+
+func TestParser_ParseExtensionFilters(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_extension_namespaces.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Equal(t, "The Example Times", feed.Title)
+	assert.NotNil(t, feed.Extensions["gen"])
+	if assert.Len(t, feed.Items, 1) {
+		assert.Equal(t, "Companies A, B in Merger Talks", feed.Items[0].Title)
+		assert.Equal(t, "http://www.example.org/images/article55.jpg", feed.Items[0].Image.Link)
+		assert.NotNil(t, feed.Items[0].Extensions["video"])
+	}
+
+	// Only the news namespace
+	fp = &sitemap.Parser{ParseExtensions: []string{"http://www.google.com/schemas/sitemap-news/0.9"}}
+	feed, err = fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Equal(t, "The Example Times", feed.Title)
+	assert.Nil(t, feed.Extensions)
+	if assert.Len(t, feed.Items, 1) {
+		assert.Equal(t, "http://www.example.org/business/article55.html", feed.Items[0].Link)
+		assert.Equal(t, "Companies A, B in Merger Talks", feed.Items[0].Title)
+		assert.Nil(t, feed.Items[0].Image)
+		assert.Nil(t, feed.Items[0].Extensions)
+	}
+
+	// Everything but images and videos
+	fp = &sitemap.Parser{SkipExtensions: []string{
+		"http://www.google.com/schemas/sitemap-image/1.1",
+		"https://www.google.com/schemas/sitemap-video/1.1/",
+	}}
+	feed, err = fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.NotNil(t, feed.Extensions["gen"])
+	if assert.Len(t, feed.Items, 1) {
+		assert.Equal(t, "Companies A, B in Merger Talks", feed.Items[0].Title)
+		assert.Nil(t, feed.Items[0].Image)
+		assert.Nil(t, feed.Items[0].Extensions)
+	}
+}
+
+func TestParser_CountURLs(t *testing.T) {
+	var countTests = []struct {
+		file  string
+		count int
+	}{
+		{"sitemap_1000_urls.xml", 1000},
+		{"sitemap_index.xml", 3},
+		{"sitemap_extension_namespaces.xml", 1},
+		{"sitemap_mixed_case.xml", 2},
+	}
+
+	fp := &sitemap.Parser{}
+	for _, test := range countTests {
+		f, _ := ioutil.ReadFile("../testdata/parser/sitemap/" + test.file)
+		count, err := fp.CountURLs(bytes.NewReader(f))
+		assert.Nil(t, err, test.file)
+		assert.Equal(t, test.count, count, test.file)
+	}
+
+	_, err := fp.CountURLs(strings.NewReader(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>http://www.example.com/</loc>`))
+	assert.NotNil(t, err)
+	_, err = fp.CountURLs(strings.NewReader(`<rss version="2.0"><channel></channel></rss>`))
+	assert.NotNil(t, err)
+
+	// No items are built, so counting takes a fraction of
+	// the allocations of parsing
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_1000_urls.xml")
+	countAllocs := testing.AllocsPerRun(5, func() {
+		fp.CountURLs(bytes.NewReader(f))
+	})
+	parseAllocs := testing.AllocsPerRun(5, func() {
+		fp.Parse(bytes.NewReader(f))
+	})
+	assert.True(t, countAllocs < parseAllocs/2, "CountURLs made %v allocations, Parse %v", countAllocs, parseAllocs)
+}
+
+func TestParser_ParseStripHTML(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_news_html_title.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	if assert.Len(t, feed.Items, 2) {
+		assert.Equal(t, "<b>Breaking</b> &#8211; the company&#8217;s results", feed.Items[0].Title)
+		assert.Equal(t, "<b>Fish &amp; chips</b>", feed.Items[1].Title)
+	}
+
+	fp = &sitemap.Parser{StripHTML: true}
+	feed, err = fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	if assert.Len(t, feed.Items, 2) {
+		assert.Equal(t, "Breaking – the company’s results", feed.Items[0].Title)
+		assert.Equal(t, "Fish & chips", feed.Items[1].Title)
+	}
+}
+
+func TestParser_ParseNewsFeed(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemao01_news.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Equal(t, "0.9", feed.Version)
+	assert.Equal(t, "Q13 FOX News", feed.Title)
+	assert.Equal(t, "en", feed.Language)
+	if assert.Len(t, feed.Items, 35) {
+		assert.Equal(t, "http://q13fox.com/2016/11/20/2016-american-music-awards-top-moments-and-winners/", feed.Items[0].Link)
+		assert.Equal(t, "2016 American Music Awards top moments and winners", feed.Items[0].Title)
+	}
+}
+
+func TestFeed_Publications(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_news_publications.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Equal(t, "The Example Times", feed.Title)
+	assert.Equal(t, "en", feed.Language)
+	assert.Equal(t, map[string]int{"The Example Times": 2, "Der Beispielbote": 1}, feed.Publications())
+	assert.Equal(t, "Der Beispielbote", feed.Items[1].Publication)
+	assert.Equal(t, "", feed.Items[2].Publication)
+
+	f, _ = ioutil.ReadFile("../testdata/parser/sitemap/sitemap_news_mixed.xml")
+	feed, err = fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Equal(t, "The Example Times", feed.Title)
+	assert.Equal(t, "en", feed.Language)
+	assert.Equal(t, map[string]int{"The Example Times": 2}, feed.Publications())
+}
+
+func TestParser_ParseLongLoc(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_long_loc.xml")
+
+	// Long locs are kept by default
+	fp := &sitemap.Parser{CollectWarnings: true}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	if assert.Len(t, feed.Items, 2) {
+		assert.Len(t, feed.Items[0].Link, 3000)
+	}
+	assert.Equal(t, []string{"loc of 3000 characters exceeds 2048"}, feed.Warnings)
+
+	fp = &sitemap.Parser{MaxLocLength: 2048}
+	feed, err = fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, feed)
+	assert.EqualError(t, err, "loc of 3000 characters exceeds the maximum of 2048")
+
+	fp = &sitemap.Parser{MaxLocLength: 4096}
+	feed, err = fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Len(t, feed.Items, 2)
+
+	// The length is counted in characters, not bytes
+	loc := "http://www.example.com/" + strings.Repeat("é", 10)
+	fp = &sitemap.Parser{MaxLocLength: 33}
+	feed, err = fp.Parse(strings.NewReader(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>` + loc + `</loc></url></urlset>`))
+	assert.Nil(t, err)
+	assert.Equal(t, loc, feed.Items[0].Link)
+
+	// and applies to index and image locs too
+	fp = &sitemap.Parser{MaxLocLength: 32}
+	_, err = fp.Parse(strings.NewReader(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><sitemap><loc>` + loc + `</loc></sitemap></sitemapindex>`))
+	assert.EqualError(t, err, "loc of 33 characters exceeds the maximum of 32")
+	_, err = fp.Parse(strings.NewReader(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">
+<url><loc>http://www.example.com/</loc><image:image><image:loc>` + loc + `</image:loc></image:image></url></urlset>`))
+	assert.EqualError(t, err, "loc of 33 characters exceeds the maximum of 32")
+}
+
+func TestParser_ParseFutureDates(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_news_future.xml")
+
+	// Future dates are kept by default
+	fp := &sitemap.Parser{CollectWarnings: true}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	if assert.Len(t, feed.Items, 2) {
+		assert.Equal(t, "2099-12-23T00:00:00Z", feed.Items[1].PubDateParsed.Format(time.RFC3339))
+	}
+	assert.Equal(t, []string{"publication_date 2099-12-23T00:00:00Z is in the future"}, feed.Warnings)
+
+	before := time.Now().UTC()
+	fp = &sitemap.Parser{ClampFutureDates: true, FutureDateTolerance: time.Hour}
+	feed, err = fp.Parse(bytes.NewReader(f))
+	after := time.Now().UTC()
+	assert.Nil(t, err)
+	if assert.Len(t, feed.Items, 2) {
+		assert.Equal(t, "2008-12-23T00:00:00Z", feed.Items[0].PubDateParsed.Format(time.RFC3339))
+		assert.Equal(t, "2099-12-23T00:00:00Z", feed.Items[1].PubDate)
+		clamped := *feed.Items[1].PubDateParsed
+		assert.False(t, clamped.Before(before))
+		assert.False(t, clamped.After(after))
+	}
+}
+
+func TestParser_ParseFutureDatesFixedClock(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_news_future.xml")
+
+	// With the clock half a day before the past date, both
+	// dates are too far in the future
+	now := time.Date(2008, 12, 22, 12, 0, 0, 0, time.UTC)
+	fp := &sitemap.Parser{
+		ClampFutureDates:    true,
+		FutureDateTolerance: time.Hour,
+		Now:                 func() time.Time { return now },
+	}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	if assert.Len(t, feed.Items, 2) {
+		assert.Equal(t, now, *feed.Items[0].PubDateParsed)
+		assert.Equal(t, now, *feed.Items[1].PubDateParsed)
+		assert.Equal(t, "2008-12-23", feed.Items[0].PubDate)
+	}
+
+	// A day later the first date is in tolerance
+	now = now.AddDate(0, 0, 1)
+	feed, err = fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	if assert.Len(t, feed.Items, 2) {
+		assert.Equal(t, "2008-12-23T00:00:00Z", feed.Items[0].PubDateParsed.Format(time.RFC3339))
+		assert.Equal(t, now, *feed.Items[1].PubDateParsed)
+	}
+}
+
+func TestParser_ParseXMLLang(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_xml_lang.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Equal(t, "de_ch", feed.Language)
+
+	fp = &sitemap.Parser{NormalizeLanguage: true}
+	feed, err = fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Equal(t, "de-CH", feed.Language)
+
+	// The news publication language wins over xml:lang
+	f, _ = ioutil.ReadFile("../testdata/parser/sitemap/sitemao01_news.xml")
+	feed, err = fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Equal(t, "en", feed.Language)
+}
+
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestParser_ParseMaxItems(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_1000_urls.xml")
+
+	r := &countingReader{r: bytes.NewReader(f)}
+	fp := &sitemap.Parser{MaxItems: 10}
+	feed, err := fp.Parse(r)
+	assert.Nil(t, err)
+	if assert.Len(t, feed.Items, 10) {
+		assert.Equal(t, "http://www.example.com/page/10", feed.Items[9].Link)
+	}
+	assert.Equal(t, "0.9", feed.Version)
+	// Only the buffered start of the input was read
+	assert.True(t, r.n < len(f)/4, "read %d of %d bytes", r.n, len(f))
+
+	fp = &sitemap.Parser{}
+	feed, err = fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Len(t, feed.Items, 1000)
+}
+
+func TestParser_ParseStream(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_1000_urls.xml")
+
+	links := []string{}
+	collect := func(item *sitemap.Item) error {
+		links = append(links, item.Link)
+		return nil
+	}
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.ParseStream(bytes.NewReader(f), collect)
+	assert.Nil(t, err)
+	assert.Nil(t, feed.Items)
+	assert.Equal(t, "0.9", feed.Version)
+	assert.Len(t, links, 1000)
+	assert.Equal(t, "http://www.example.com/page/1000", links[999])
+
+	// Past MaxItems urls the stream is rejected
+	links = []string{}
+	fp = &sitemap.Parser{MaxItems: 10}
+	feed, err = fp.ParseStream(bytes.NewReader(f), collect)
+	assert.Nil(t, feed)
+	assert.EqualError(t, err, "sitemap has more than the maximum of 10 urls")
+	assert.Len(t, links, 10)
+
+	links = []string{}
+	fp = &sitemap.Parser{MaxItems: 1000}
+	_, err = fp.ParseStream(bytes.NewReader(f), collect)
+	assert.Nil(t, err)
+	assert.Len(t, links, 1000)
+
+	// The callback and the context can stop the stream
+	fp = &sitemap.Parser{}
+	stop := errors.New("stop")
+	_, err = fp.ParseStream(bytes.NewReader(f), func(item *sitemap.Item) error { return stop })
+	assert.Equal(t, stop, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	links = []string{}
+	_, err = fp.ParseStreamWithContext(ctx, bytes.NewReader(f), func(item *sitemap.Item) error {
+		links = append(links, item.Link)
+		if len(links) == 5 {
+			cancel()
+		}
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Len(t, links, 5)
+}
+
+func TestParser_ParseChan(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_1000_urls.xml")
+
+	fp := &sitemap.Parser{}
+	items, errs := fp.ParseChan(bytes.NewReader(f))
+	links := []string{}
+	for item := range items {
+		links = append(links, item.Link)
+	}
+	assert.Nil(t, <-errs)
+	assert.Len(t, links, 1000)
+	assert.Equal(t, "http://www.example.com/page/1000", links[999])
+
+	items, errs = fp.ParseChan(strings.NewReader("<urlset><url>"))
+	for range items {
+	}
+	assert.NotNil(t, <-errs)
+
+	// Cancelling stops a parse no one reads from anymore
+	ctx, cancel := context.WithCancel(context.Background())
+	items, errs = fp.ParseChanWithContext(ctx, bytes.NewReader(f))
+	<-items
+	<-items
+	cancel()
+	assert.Equal(t, context.Canceled, <-errs)
+	_, open := <-items
+	assert.False(t, open)
+}
+
+func TestParser_Iterator(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_1000_urls.xml")
+
+	fp := &sitemap.Parser{}
+	it, err := fp.Iterator(bytes.NewReader(f))
+	assert.Nil(t, err)
+	links := []string{}
+	for {
+		item, err := it.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		links = append(links, item.Link)
+	}
+	assert.Len(t, links, 1000)
+	assert.Equal(t, "http://www.example.com/page/1", links[0])
+	assert.Equal(t, "http://www.example.com/page/1000", links[999])
+
+	// The end is sticky
+	item, err := it.Next()
+	assert.Nil(t, item)
+	assert.Equal(t, io.EOF, err)
+
+	// MaxItems and Close end the iteration early
+	fp = &sitemap.Parser{MaxItems: 2}
+	it, err = fp.Iterator(bytes.NewReader(f))
+	assert.Nil(t, err)
+	_, err = it.Next()
+	assert.Nil(t, err)
+	_, err = it.Next()
+	assert.Nil(t, err)
+	_, err = it.Next()
+	assert.Equal(t, io.EOF, err)
+
+	fp = &sitemap.Parser{}
+	it, err = fp.Iterator(bytes.NewReader(f))
+	assert.Nil(t, err)
+	it.Close()
+	_, err = it.Next()
+	assert.Equal(t, io.EOF, err)
+
+	f, _ = ioutil.ReadFile("../testdata/parser/sitemap/sitemap_index.xml")
+	_, err = fp.Iterator(bytes.NewReader(f))
+	assert.NotNil(t, err)
+}
+
+func TestParser_ParsePrefixedNamespace(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_prefixed_ns.xml")
+
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(bytes.NewReader(f))
+	assert.Nil(t, err)
+	assert.Equal(t, "0.9", feed.Version)
+	assert.Equal(t, "Example News", feed.Title)
+	assert.Nil(t, feed.Extensions)
+	if assert.Len(t, feed.Items, 2) {
+		assert.Equal(t, "http://www.example.org/first", feed.Items[0].Link)
+		assert.Equal(t, "First", feed.Items[0].Title)
+		assert.Equal(t, "daily", feed.Items[0].ChangeFreq)
+		assert.Equal(t, "0.8", feed.Items[0].Priority)
+		assert.Equal(t, "http://www.example.org/second", feed.Items[1].Link)
+	}
+}
+
+func TestParser_ParseForeignLoc(t *testing.T) {
+	fp := &sitemap.Parser{}
+	feed, err := fp.Parse(strings.NewReader(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:vendor="http://www.example.com/schemas/vendor">
+<url><vendor:loc>http://cdn.example.com/page</vendor:loc><loc>http://www.example.com/page</loc><vendor:lastmod>2001-01-01</vendor:lastmod></url>
+<url><loc>http://www.example.com/other</loc></url>
+</urlset>`))
+	assert.Nil(t, err)
+	if assert.Len(t, feed.Items, 2) {
+		assert.Equal(t, "http://www.example.com/page", feed.Items[0].Link)
+		assert.Equal(t, "", feed.Items[0].LastMod)
+	}
+
+	feed, err = fp.Parse(strings.NewReader(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:vendor="http://www.example.com/schemas/vendor">
+<sitemap><loc>http://www.example.com/sitemap1.xml</loc><vendor:loc>http://cdn.example.com/sitemap1.xml</vendor:loc></sitemap>
+</sitemapindex>`))
+	assert.Nil(t, err)
+	if assert.Len(t, feed.Sitemaps, 1) {
+		assert.Equal(t, "http://www.example.com/sitemap1.xml", feed.Sitemaps[0].Link)
+	}
+}
+
+func BenchmarkParseLargeSitemap(b *testing.B) {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+`)
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&buf, "<url><loc>http://www.example.com/page/%d</loc><lastmod>2017-01-02</lastmod></url>\n", i)
+	}
+	buf.WriteString("</urlset>\n")
+	data := buf.Bytes()
+
+	fp := &sitemap.Parser{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := fp.Parse(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCountURLs(b *testing.B) {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+`)
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&buf, "<url><loc>http://www.example.com/page/%d</loc><lastmod>2017-01-02</lastmod></url>\n", i)
+	}
+	buf.WriteString("</urlset>\n")
+	data := buf.Bytes()
+
+	fp := &sitemap.Parser{}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := fp.CountURLs(bytes.NewReader(data)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseExtensions(b *testing.B) {
+	var buf bytes.Buffer
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"
+	xmlns:news="http://www.google.com/schemas/sitemap-news/0.9"
+	xmlns:image="http://www.google.com/schemas/sitemap-image/1.1"
+	xmlns:video="http://www.google.com/schemas/sitemap-video/1.1">
+`)
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&buf, `<url><loc>http://www.example.com/page/%d</loc>`+
+			`<news:news><news:publication><news:name>Example</news:name></news:publication><news:title>Page %d</news:title></news:news>`+
+			`<image:image><image:loc>http://www.example.com/page/%d.jpg</image:loc></image:image>`+
+			`<video:video><video:title>Page %d</video:title><video:content_loc>http://www.example.com/page/%d.mp4</video:content_loc></video:video>`+
+			"</url>\n", i, i, i, i, i)
+	}
+	buf.WriteString("</urlset>\n")
+	data := buf.Bytes()
+
+	parsers := map[string]*sitemap.Parser{
+		"All":      {},
+		"NewsOnly": {ParseExtensions: []string{"http://www.google.com/schemas/sitemap-news/0.9"}},
+	}
+	for name, fp := range parsers {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := fp.Parse(bytes.NewReader(data)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 // TODO: Examples
@@ -0,0 +1,178 @@
+package sitemap
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+
+// WriteSitemapSet writes the items of the feed as a set of
+// urlset files of at most maxPerFile urls each, plus a
+// sitemapindex referencing them.  w is called with 0 for the
+// index and with 1..n for the urlsets, and loc returns the
+// public url of urlset i to list in the index.  The protocol
+// allows at most 50000 urls per file.
+func (f Feed) WriteSitemapSet(w func(index int) io.Writer, loc func(index int) string, maxPerFile int) error {
+	if maxPerFile <= 0 {
+		return fmt.Errorf("maxPerFile must be positive, got %d", maxPerFile)
+	}
+
+	refs := []*SitemapRef{}
+	for i, start := 1, 0; start < len(f.Items); i, start = i+1, start+maxPerFile {
+		end := start + maxPerFile
+		if end > len(f.Items) {
+			end = len(f.Items)
+		}
+		chunk := f.Items[start:end]
+
+		if err := f.writeURLSet(w(i), chunk); err != nil {
+			return err
+		}
+		refs = append(refs, &SitemapRef{Link: loc(i), LastModParsed: latestLastMod(chunk)})
+	}
+
+	return writeIndex(w(0), refs)
+}
+
+func (f Feed) writeURLSet(out io.Writer, items []*Item) error {
+	bw := bufio.NewWriter(out)
+
+	// Only declare the namespaces the file uses
+	var news, image, geo, alternates bool
+	for _, item := range items {
+		news = news || item.Title != "" || item.PubDate != ""
+		image = image || item.Image != nil || len(item.Images) > 0
+		geo = geo || item.Geo != nil
+		alternates = alternates || len(item.Alternates) > 0
+	}
+
+	bw.WriteString(xmlHeader)
+	bw.WriteString(`<urlset xmlns="` + sitemapNS + `"`)
+	if news {
+		bw.WriteString(` xmlns:news="` + newsNS + `"`)
+	}
+	if image {
+		bw.WriteString(` xmlns:image="` + imageNS + `"`)
+	}
+	if geo {
+		bw.WriteString(` xmlns:geo="` + geoNS + `"`)
+	}
+	if alternates {
+		bw.WriteString(` xmlns:xhtml="` + xhtmlNS + `"`)
+	}
+	bw.WriteString(">\n")
+
+	for _, item := range items {
+		bw.WriteString("<url>")
+		writeElement(bw, "loc", item.Link)
+		writeElement(bw, "lastmod", lastModText(item.LastMod, item.LastModParsed))
+		writeElement(bw, "changefreq", item.ChangeFreq)
+		writeElement(bw, "priority", item.Priority)
+		for _, alt := range item.Alternates {
+			bw.WriteString(`<xhtml:link rel="alternate" hreflang="`)
+			xml.EscapeText(bw, []byte(alt.Lang))
+			bw.WriteString(`" href="`)
+			xml.EscapeText(bw, []byte(alt.Link))
+			bw.WriteString(`"/>`)
+		}
+		if item.Title != "" || item.PubDate != "" {
+			bw.WriteString("<news:news><news:publication>")
+			name := item.Publication
+			if name == "" {
+				name = f.Title
+			}
+			writeElement(bw, "news:name", name)
+			writeElement(bw, "news:language", f.Language)
+			bw.WriteString("</news:publication>")
+			writeElement(bw, "news:publication_date", item.PubDate)
+			writeElement(bw, "news:title", item.Title)
+			bw.WriteString("</news:news>")
+		}
+		images := item.Images
+		if len(images) == 0 && item.Image != nil {
+			images = []*Image{item.Image}
+		}
+		for _, img := range images {
+			bw.WriteString("<image:image>")
+			writeElement(bw, "image:loc", img.Link)
+			bw.WriteString("</image:image>")
+		}
+		if item.Geo != nil {
+			bw.WriteString("<geo:geo>")
+			writeElement(bw, "geo:format", item.Geo.Format)
+			bw.WriteString("</geo:geo>")
+		}
+		bw.WriteString("</url>\n")
+	}
+
+	bw.WriteString("</urlset>\n")
+	return bw.Flush()
+}
+
+func writeIndex(out io.Writer, refs []*SitemapRef) error {
+	bw := bufio.NewWriter(out)
+	bw.WriteString(xmlHeader)
+	bw.WriteString(`<sitemapindex xmlns="` + sitemapNS + `">` + "\n")
+	for _, ref := range refs {
+		bw.WriteString("<sitemap>")
+		writeElement(bw, "loc", ref.Link)
+		writeElement(bw, "lastmod", lastModText(ref.LastMod, ref.LastModParsed))
+		bw.WriteString("</sitemap>\n")
+	}
+	bw.WriteString("</sitemapindex>\n")
+	return bw.Flush()
+}
+
+// writeElement writes a text element, omitting empty values
+func writeElement(bw *bufio.Writer, name, value string) {
+	if value == "" {
+		return
+	}
+	bw.WriteString("<" + name + ">")
+	xml.EscapeText(bw, []byte(value))
+	bw.WriteString("</" + name + ">")
+}
+
+func lastModText(raw string, parsed *time.Time) string {
+	if raw != "" {
+		return raw
+	}
+	if parsed != nil {
+		return parsed.UTC().Format(time.RFC3339)
+	}
+	return ""
+}
+
+// latestLastMod is the most recent lastmod of items, the
+// lastmod of the urlset listing them.
+func latestLastMod(items []*Item) (latest *time.Time) {
+	for _, item := range items {
+		if item.LastModParsed != nil && (latest == nil || item.LastModParsed.After(*latest)) {
+			latest = item.LastModParsed
+		}
+	}
+	return
+}
+
+// WriteNDJSON writes the urls received on items to w as
+// newline delimited JSON, one url per line, until the channel
+// is closed.  Combined with ParseStream it turns a sitemap of
+// any size into JSON without holding it in memory.  After a
+// write error the channel is still drained, so its sender
+// isn't left blocked.
+func WriteNDJSON(w io.Writer, items <-chan *Item) error {
+	enc := json.NewEncoder(w)
+	for item := range items {
+		if err := enc.Encode(item); err != nil {
+			for range items {
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package sitemap_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/shuyaoyimei/gofeed/sitemap"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeed_WriteSitemapSet(t *testing.T) {
+	feb := time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	feed := sitemap.Feed{}
+	for i := 0; i < 120000; i++ {
+		feed.Items = append(feed.Items, &sitemap.Item{Link: fmt.Sprintf("http://www.example.com/%d", i)})
+	}
+	feed.Items[60000].LastModParsed = &feb
+
+	files := map[int]*bytes.Buffer{}
+	w := func(i int) io.Writer {
+		files[i] = &bytes.Buffer{}
+		return files[i]
+	}
+	loc := func(i int) string {
+		return fmt.Sprintf("http://www.example.com/sitemap-%d.xml", i)
+	}
+
+	err := feed.WriteSitemapSet(w, loc, 50000)
+	assert.Nil(t, err)
+	assert.Len(t, files, 4)
+
+	fp := &sitemap.Parser{}
+	index, err := fp.Parse(files[0])
+	assert.Nil(t, err)
+	if assert.Len(t, index.Sitemaps, 3) {
+		assert.Equal(t, "http://www.example.com/sitemap-1.xml", index.Sitemaps[0].Link)
+		assert.Equal(t, "http://www.example.com/sitemap-3.xml", index.Sitemaps[2].Link)
+		assert.Nil(t, index.Sitemaps[0].LastModParsed)
+		assert.Equal(t, &feb, index.Sitemaps[1].LastModParsed)
+	}
+
+	for i, count := range []int{50000, 50000, 20000} {
+		urlset, err := fp.Parse(files[i+1])
+		assert.Nil(t, err)
+		assert.Len(t, urlset.Items, count)
+		assert.Equal(t, fmt.Sprintf("http://www.example.com/%d", i*50000), urlset.Items[0].Link)
+	}
+
+	err = feed.WriteSitemapSet(w, loc, 0)
+	assert.NotNil(t, err)
+}
+
+func TestFeed_WriteSitemapSetRoundTrip(t *testing.T) {
+	feed := sitemap.Feed{
+		Title:    "The Example Times",
+		Language: "en",
+		Items: []*sitemap.Item{
+			{
+				Link:       "http://www.example.com/a?x=1&y=2",
+				LastMod:    "2017-01-01",
+				ChangeFreq: "daily",
+				Priority:   "0.8",
+				Title:      "Fish & Chips",
+				PubDate:    "2017-01-01T12:00:00Z",
+				Image:      &sitemap.Image{Link: "http://www.example.com/a.jpg"},
+				Alternates: []*sitemap.Alternate{{Link: "http://www.example.com/de/a", Lang: "de"}},
+			},
+			{Link: "http://www.example.com/b"},
+		},
+	}
+
+	files := map[int]*bytes.Buffer{}
+	w := func(i int) io.Writer {
+		files[i] = &bytes.Buffer{}
+		return files[i]
+	}
+	err := feed.WriteSitemapSet(w, func(i int) string { return "http://www.example.com/sitemap.xml" }, 10)
+	assert.Nil(t, err)
+
+	fp := &sitemap.Parser{}
+	urlset, err := fp.Parse(files[1])
+	assert.Nil(t, err)
+	assert.Empty(t, urlset.Warnings)
+	assert.Equal(t, "The Example Times", urlset.Title)
+	assert.Equal(t, "en", urlset.Language)
+	if assert.Len(t, urlset.Items, 2) {
+		a := urlset.Items[0]
+		assert.Equal(t, "http://www.example.com/a?x=1&y=2", a.Link)
+		assert.Equal(t, "2017-01-01", a.LastMod)
+		assert.Equal(t, "daily", a.ChangeFreq)
+		assert.Equal(t, "0.8", a.Priority)
+		assert.Equal(t, "Fish & Chips", a.Title)
+		assert.Equal(t, "2017-01-01T12:00:00Z", a.PubDate)
+		assert.Equal(t, "http://www.example.com/a.jpg", a.Image.Link)
+		assert.Equal(t, []*sitemap.Alternate{{Link: "http://www.example.com/de/a", Lang: "de"}}, a.Alternates)
+		assert.Equal(t, "http://www.example.com/b", urlset.Items[1].Link)
+	}
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	f, _ := ioutil.ReadFile("../testdata/parser/sitemap/sitemap_1000_urls.xml")
+
+	items := make(chan *sitemap.Item)
+	errs := make(chan error, 1)
+	go func() {
+		fp := &sitemap.Parser{}
+		_, err := fp.ParseStream(bytes.NewReader(f), func(item *sitemap.Item) error {
+			items <- item
+			return nil
+		})
+		close(items)
+		errs <- err
+	}()
+
+	var buf bytes.Buffer
+	err := sitemap.WriteNDJSON(&buf, items)
+	assert.Nil(t, err)
+	assert.Nil(t, <-errs)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if assert.Len(t, lines, 1000) {
+		for i, line := range lines {
+			item := &sitemap.Item{}
+			assert.Nil(t, json.Unmarshal([]byte(line), item), "line %d is not valid json", i)
+			assert.Equal(t, fmt.Sprintf("http://www.example.com/page/%d", i+1), item.Link)
+		}
+	}
+
+	// A failing writer still drains the channel
+	items = make(chan *sitemap.Item)
+	go func() {
+		for i := 0; i < 10; i++ {
+			items <- &sitemap.Item{}
+		}
+		close(items)
+	}()
+	err = sitemap.WriteNDJSON(errWriter{}, items)
+	assert.NotNil(t, err)
+}
+
+type errWriter struct{}
+
+func (errWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("write failed")
+}
@@ -2,6 +2,7 @@ package gofeed
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -39,6 +40,8 @@ func (t *DefaultRSSTranslator) Translate(feed interface{}) (*Feed, error) {
 	result.Description = t.translateFeedDescription(rss)
 	result.Link = t.translateFeedLink(rss)
 	result.FeedLink = t.translateFeedFeedLink(rss)
+	result.HubLink = t.translateFeedHubLink(rss)
+	result.Cloud = t.translateFeedCloud(rss)
 	result.Updated = t.translateFeedUpdated(rss)
 	result.UpdatedParsed = t.translateFeedUpdatedParsed(rss)
 	result.Published = t.translateFeedPublished(rss)
@@ -48,8 +51,13 @@ func (t *DefaultRSSTranslator) Translate(feed interface{}) (*Feed, error) {
 	result.Image = t.translateFeedImage(rss)
 	result.Copyright = t.translateFeedCopyright(rss)
 	result.Generator = t.translateFeedGenerator(rss)
+	result.Docs = t.translateFeedDocs(rss)
 	result.Categories = t.translateFeedCategories(rss)
+	result.TTL = t.translateFeedTTL(rss)
+	result.SkipHours = t.translateFeedSkipHours(rss)
+	result.SkipDays = t.translateFeedSkipDays(rss)
 	result.Items = t.translateFeedItems(rss)
+	result.ITunesExt = rss.ITunesExt
 	result.Extensions = rss.Extensions
 	result.FeedVersion = rss.Version
 	result.FeedType = "rss"
@@ -61,6 +69,8 @@ func (t *DefaultRSSTranslator) translateFeedItem(rssItem *rss.Item) (item *Item)
 	item.Title = t.translateItemTitle(rssItem)
 	item.Description = t.translateItemDescription(rssItem)
 	item.Link = t.translateItemLink(rssItem)
+	item.Updated = t.translateItemUpdated(rssItem)
+	item.UpdatedParsed = t.translateItemUpdatedParsed(rssItem)
 	item.Published = t.translateItemPublished(rssItem)
 	item.PublishedParsed = t.translateItemPublishedParsed(rssItem)
 	item.Author = t.translateItemAuthor(rssItem)
@@ -68,6 +78,11 @@ func (t *DefaultRSSTranslator) translateFeedItem(rssItem *rss.Item) (item *Item)
 	item.Image = t.translateItemImage(rssItem)
 	item.Categories = t.translateItemCategories(rssItem)
 	item.Enclosures = t.translateItemEnclosures(rssItem)
+	item.Language = t.translateItemLanguage(rssItem)
+	item.Copyright = t.translateItemCopyright(rssItem)
+	item.Source = t.translateItemSource(rssItem)
+	item.Media = rssItem.MediaExt
+	item.ITunesExt = rssItem.ITunesExt
 	item.Extensions = rssItem.Extensions
 	return
 }
@@ -95,14 +110,21 @@ func (t *DefaultRSSTranslator) translateFeedLink(rss *rss.Feed) (link string) {
 }
 
 func (t *DefaultRSSTranslator) translateFeedFeedLink(rss *rss.Feed) (link string) {
-	atomExtensions := t.extensionsForKeys([]string{"atom", "atom10", "atom03"}, rss.Extensions)
-	for _, ex := range atomExtensions {
-		if links, ok := ex["link"]; ok {
-			for _, l := range links {
-				if l.Attrs["Rel"] == "self" {
-					link = l.Value
-				}
-			}
+	return t.atomLinkHref("self", rss.Extensions)
+}
+
+func (t *DefaultRSSTranslator) translateFeedHubLink(rss *rss.Feed) (link string) {
+	return t.atomLinkHref("hub", rss.Extensions)
+}
+
+func (t *DefaultRSSTranslator) translateFeedCloud(rss *rss.Feed) (cloud *Cloud) {
+	if rss.Cloud != nil {
+		cloud = &Cloud{
+			Domain:            rss.Cloud.Domain,
+			Port:              rss.Cloud.Port,
+			Path:              rss.Cloud.Path,
+			RegisterProcedure: rss.Cloud.RegisterProcedure,
+			Protocol:          rss.Cloud.Protocol,
 		}
 	}
 	return
@@ -113,6 +135,8 @@ func (t *DefaultRSSTranslator) translateFeedUpdated(rss *rss.Feed) (updated stri
 		updated = rss.LastBuildDate
 	} else if rss.DublinCoreExt != nil && rss.DublinCoreExt.Date != nil {
 		updated = t.firstEntry(rss.DublinCoreExt.Date)
+	} else {
+		updated = t.atomText("updated", rss.Extensions)
 	}
 	return
 }
@@ -120,8 +144,16 @@ func (t *DefaultRSSTranslator) translateFeedUpdated(rss *rss.Feed) (updated stri
 func (t *DefaultRSSTranslator) translateFeedUpdatedParsed(rss *rss.Feed) (updated *time.Time) {
 	if rss.LastBuildDateParsed != nil {
 		updated = rss.LastBuildDateParsed
-	} else if rss.DublinCoreExt != nil && rss.DublinCoreExt.Date != nil {
-		dateText := t.firstEntry(rss.DublinCoreExt.Date)
+		return
+	}
+
+	var dateText string
+	if rss.DublinCoreExt != nil && rss.DublinCoreExt.Date != nil {
+		dateText = t.firstEntry(rss.DublinCoreExt.Date)
+	} else {
+		dateText = t.atomText("updated", rss.Extensions)
+	}
+	if dateText != "" {
 		date, err := shared.ParseDate(dateText)
 		if err == nil {
 			updated = &date
@@ -204,6 +236,10 @@ func (t *DefaultRSSTranslator) translateFeedGenerator(rss *rss.Feed) (generator
 	return rss.Generator
 }
 
+func (t *DefaultRSSTranslator) translateFeedDocs(rss *rss.Feed) (docs string) {
+	return rss.Docs
+}
+
 func (t *DefaultRSSTranslator) translateFeedCategories(rss *rss.Feed) (categories []string) {
 	cats := []string{}
 	if rss.Categories != nil {
@@ -241,10 +277,53 @@ func (t *DefaultRSSTranslator) translateFeedCategories(rss *rss.Feed) (categorie
 	return
 }
 
+func (t *DefaultRSSTranslator) translateFeedTTL(rss *rss.Feed) (ttl int) {
+	if rss.TTL != "" {
+		minutes, err := strconv.Atoi(strings.TrimSpace(rss.TTL))
+		if err == nil && minutes > 0 {
+			ttl = minutes
+		}
+	}
+	return
+}
+
+func (t *DefaultRSSTranslator) translateFeedSkipHours(rss *rss.Feed) (hours []int) {
+	for _, h := range rss.SkipHours {
+		hour, err := strconv.Atoi(strings.TrimSpace(h))
+		if err == nil && hour >= 0 && hour <= 23 {
+			hours = append(hours, hour)
+		}
+	}
+	return
+}
+
+func (t *DefaultRSSTranslator) translateFeedSkipDays(rss *rss.Feed) (days []string) {
+	for _, d := range rss.SkipDays {
+		if day := strings.TrimSpace(d); day != "" {
+			days = append(days, day)
+		}
+	}
+	return
+}
+
 func (t *DefaultRSSTranslator) translateFeedItems(rss *rss.Feed) (items []*Item) {
 	items = []*Item{}
+	language := t.translateFeedLanguage(rss)
 	for _, i := range rss.Items {
-		items = append(items, t.translateFeedItem(i))
+		item := t.translateFeedItem(i)
+		// Items without a language of their own are in
+		// the language of the channel
+		if item.Language == "" {
+			item.Language = language
+		}
+		items = append(items, item)
+	}
+	return
+}
+
+func (t *DefaultRSSTranslator) translateItemLanguage(rssItem *rss.Item) (language string) {
+	if rssItem.DublinCoreExt != nil && rssItem.DublinCoreExt.Language != nil {
+		language = t.firstEntry(rssItem.DublinCoreExt.Language)
 	}
 	return
 }
@@ -290,11 +369,17 @@ func (t *DefaultRSSTranslator) translateItemUpdatedParsed(rssItem *rss.Item) (up
 }
 
 func (t *DefaultRSSTranslator) translateItemPublished(rssItem *rss.Item) (updated string) {
-	return rssItem.PubDate
+	if rssItem.PubDate != "" {
+		return rssItem.PubDate
+	}
+	return t.translateItemUpdated(rssItem)
 }
 
 func (t *DefaultRSSTranslator) translateItemPublishedParsed(rssItem *rss.Item) (updated *time.Time) {
-	return rssItem.PubDateParsed
+	if rssItem.PubDate != "" {
+		return rssItem.PubDateParsed
+	}
+	return t.translateItemUpdatedParsed(rssItem)
 }
 
 func (t *DefaultRSSTranslator) translateItemAuthor(rssItem *rss.Item) (author *Person) {
@@ -378,6 +463,22 @@ func (t *DefaultRSSTranslator) translateItemEnclosures(rssItem *rss.Item) (enclo
 	return
 }
 
+func (t *DefaultRSSTranslator) translateItemCopyright(rssItem *rss.Item) (rights string) {
+	if rssItem.DublinCoreExt != nil && rssItem.DublinCoreExt.Rights != nil {
+		rights = t.firstEntry(rssItem.DublinCoreExt.Rights)
+	}
+	return
+}
+
+func (t *DefaultRSSTranslator) translateItemSource(rssItem *rss.Item) (source *Source) {
+	if rssItem.Source != nil {
+		source = &Source{}
+		source.Title = rssItem.Source.Title
+		source.URL = rssItem.Source.URL
+	}
+	return
+}
+
 func (t *DefaultRSSTranslator) extensionsForKeys(keys []string, extensions ext.Extensions) (matches []map[string][]ext.Extension) {
 	matches = []map[string][]ext.Extension{}
 
@@ -393,6 +494,34 @@ func (t *DefaultRSSTranslator) extensionsForKeys(keys []string, extensions ext.E
 	return
 }
 
+// atomLinkHref returns the href of the first atom:link
+// embedded in an rss feed with the given rel.
+func (t *DefaultRSSTranslator) atomLinkHref(rel string, extensions ext.Extensions) (href string) {
+	atomExtensions := t.extensionsForKeys([]string{"atom", "atom10", "atom03"}, extensions)
+	for _, ex := range atomExtensions {
+		for _, l := range ex["link"] {
+			if strings.EqualFold(strings.TrimSpace(l.Attrs["rel"]), rel) {
+				return strings.TrimSpace(l.Attrs["href"])
+			}
+		}
+	}
+	return
+}
+
+// atomText returns the value of the first atom element
+// embedded in an rss feed with the given name.
+func (t *DefaultRSSTranslator) atomText(name string, extensions ext.Extensions) (value string) {
+	atomExtensions := t.extensionsForKeys([]string{"atom", "atom10", "atom03"}, extensions)
+	for _, ex := range atomExtensions {
+		for _, e := range ex[name] {
+			if e.Value != "" {
+				return e.Value
+			}
+		}
+	}
+	return
+}
+
 func (t *DefaultRSSTranslator) firstEntry(entries []string) (value string) {
 	if entries == nil {
 		return
@@ -426,6 +555,7 @@ func (t *DefaultAtomTranslator) Translate(feed interface{}) (*Feed, error) {
 	result.Description = t.translateFeedDescription(atom)
 	result.Link = t.translateFeedLink(atom)
 	result.FeedLink = t.translateFeedFeedLink(atom)
+	result.HubLink = t.translateFeedHubLink(atom)
 	result.Updated = t.translateFeedUpdated(atom)
 	result.UpdatedParsed = t.translateFeedUpdatedParsed(atom)
 	result.Author = t.translateFeedAuthor(atom)
@@ -447,6 +577,7 @@ func (t *DefaultAtomTranslator) translateFeedItem(entry *atom.Entry) (item *Item
 	item.Description = t.translateItemDescription(entry)
 	item.Content = t.translateItemContent(entry)
 	item.Link = t.translateItemLink(entry)
+	item.Links = t.translateItemLinks(entry)
 	item.Updated = t.translateItemUpdated(entry)
 	item.UpdatedParsed = t.translateItemUpdatedParsed(entry)
 	item.Published = t.translateItemPublished(entry)
@@ -456,6 +587,9 @@ func (t *DefaultAtomTranslator) translateFeedItem(entry *atom.Entry) (item *Item
 	item.Image = t.translateItemImage(entry)
 	item.Categories = t.translateItemCategories(entry)
 	item.Enclosures = t.translateItemEnclosures(entry)
+	item.Language = entry.Language
+	item.Copyright = entry.Rights
+	item.Media = t.translateItemMedia(entry)
 	item.Extensions = entry.Extensions
 	return
 }
@@ -484,6 +618,14 @@ func (t *DefaultAtomTranslator) translateFeedFeedLink(atom *atom.Feed) (link str
 	return
 }
 
+func (t *DefaultAtomTranslator) translateFeedHubLink(atom *atom.Feed) (link string) {
+	hubLink := t.firstLinkWithType("hub", atom.Links)
+	if hubLink != nil {
+		link = hubLink.Href
+	}
+	return
+}
+
 func (t *DefaultAtomTranslator) translateFeedUpdated(atom *atom.Feed) (updated string) {
 	return atom.Updated
 }
@@ -537,19 +679,20 @@ func (t *DefaultAtomTranslator) translateFeedGenerator(atom *atom.Feed) (generat
 }
 
 func (t *DefaultAtomTranslator) translateFeedCategories(atom *atom.Feed) (categories []string) {
-	if atom.Categories != nil {
-		categories = []string{}
-		for _, c := range atom.Categories {
-			categories = append(categories, c.Term)
-		}
-	}
-	return
+	return t.categoryNames(atom.Categories)
 }
 
 func (t *DefaultAtomTranslator) translateFeedItems(atom *atom.Feed) (items []*Item) {
 	items = []*Item{}
+	language := t.translateFeedLanguage(atom)
 	for _, entry := range atom.Entries {
-		items = append(items, t.translateFeedItem(entry))
+		item := t.translateFeedItem(entry)
+		// xml:lang is inherited, so entries without one
+		// are in the language of the feed
+		if item.Language == "" {
+			item.Language = language
+		}
+		items = append(items, item)
 	}
 	return
 }
@@ -569,6 +712,13 @@ func (t *DefaultAtomTranslator) translateItemContent(entry *atom.Entry) (content
 	return
 }
 
+func (t *DefaultAtomTranslator) translateItemMedia(entry *atom.Entry) (media *ext.MediaExtension) {
+	if m, ok := entry.Extensions["media"]; ok {
+		media = ext.NewMediaExtension(m)
+	}
+	return
+}
+
 func (t *DefaultAtomTranslator) translateItemLink(entry *atom.Entry) (link string) {
 	l := t.firstLinkWithType("alternate", entry.Links)
 	if l != nil {
@@ -577,6 +727,20 @@ func (t *DefaultAtomTranslator) translateItemLink(entry *atom.Entry) (link strin
 	return
 }
 
+func (t *DefaultAtomTranslator) translateItemLinks(entry *atom.Entry) (links []*Link) {
+	for _, l := range entry.Links {
+		links = append(links, &Link{
+			Href:     l.Href,
+			Rel:      l.Rel,
+			Type:     l.Type,
+			Title:    l.Title,
+			Hreflang: l.Hreflang,
+			Length:   l.Length,
+		})
+	}
+	return
+}
+
 func (t *DefaultAtomTranslator) translateItemUpdated(entry *atom.Entry) (updated string) {
 	return entry.Updated
 }
@@ -612,10 +776,22 @@ func (t *DefaultAtomTranslator) translateItemImage(entry *atom.Entry) (image *Im
 }
 
 func (t *DefaultAtomTranslator) translateItemCategories(entry *atom.Entry) (categories []string) {
-	if entry.Categories != nil {
-		categories = []string{}
-		for _, c := range entry.Categories {
+	return t.categoryNames(entry.Categories)
+}
+
+// categoryNames returns the term of each category, falling back
+// to its label for feeds that leave the required term empty.
+func (t *DefaultAtomTranslator) categoryNames(cats []*atom.Category) (categories []string) {
+	if cats == nil {
+		return
+	}
+
+	categories = []string{}
+	for _, c := range cats {
+		if c.Term != "" {
 			categories = append(categories, c.Term)
+		} else if c.Label != "" {
+			categories = append(categories, c.Label)
 		}
 	}
 	return
@@ -625,7 +801,7 @@ func (t *DefaultAtomTranslator) translateItemEnclosures(entry *atom.Entry) (encl
 	if entry.Links != nil {
 		enclosures = []*Enclosure{}
 		for _, e := range entry.Links {
-			if e.Rel == "enclosure" {
+			if atomRel(e.Rel) == "enclosure" {
 				enclosure := &Enclosure{}
 				enclosure.URL = e.Href
 				enclosure.Length = e.Length
@@ -647,13 +823,21 @@ func (t *DefaultAtomTranslator) firstLinkWithType(linkType string, links []*atom
 	}
 
 	for _, link := range links {
-		if link.Rel == linkType {
+		if atomRel(link.Rel) == linkType {
 			return link
 		}
 	}
 	return nil
 }
 
+// atomRel normalizes a link relation, which may also be given
+// in any case or as its full IANA registry IRI, to its short
+// lower case name.
+func atomRel(rel string) string {
+	rel = strings.ToLower(strings.TrimSpace(rel))
+	return strings.TrimPrefix(rel, "http://www.iana.org/assignments/relation/")
+}
+
 func (t *DefaultAtomTranslator) firstPerson(persons []*atom.Person) (person *atom.Person) {
 	if persons == nil || len(persons) == 0 {
 		return
@@ -683,6 +867,11 @@ func (t *DefaultSitemapTranslator) Translate(feed interface{}) (*Feed, error) {
 	result.Title = t.translateFeedTitle(sitemap)
 	result.Language = sitemap.Language
 	result.Items = t.translateFeedItems(sitemap)
+	result.UpdatedParsed = sitemap.LastModified
+	if sitemap.LastModified != nil {
+		result.Updated = sitemap.LastModified.Format(time.RFC3339)
+	}
+	result.Extensions = sitemap.Extensions
 	result.FeedVersion = sitemap.Version
 	result.FeedType = "rss"
 	return result, nil
@@ -721,6 +910,7 @@ func (t *DefaultSitemapTranslator) translateFeedItem(sitemapItem *sitemap.Item)
 	item.Published = t.translateItemPublished(sitemapItem)
 	item.PublishedParsed = t.translateItemPublishedParsed(sitemapItem)
 	item.Image = t.translateItemImage(sitemapItem)
+	item.Sitemap = t.translateItemSitemap(sitemapItem)
 	return
 }
 
@@ -752,3 +942,16 @@ func (t *DefaultSitemapTranslator) translateItemImage(sitemapItem *sitemap.Item)
 	}
 	return
 }
+
+func (t *DefaultSitemapTranslator) translateItemSitemap(sitemapItem *sitemap.Item) (extra *SitemapExtra) {
+	if sitemapItem.LastMod == "" && sitemapItem.ChangeFreq == "" && sitemapItem.Priority == "" {
+		return nil
+	}
+
+	extra = &SitemapExtra{}
+	extra.LastMod = sitemapItem.LastMod
+	extra.LastModParsed = sitemapItem.LastModParsed
+	extra.ChangeFreq = sitemapItem.ChangeFreq
+	extra.Priority = sitemapItem.Priority
+	return
+}
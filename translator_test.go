@@ -12,6 +12,7 @@ import (
 	"github.com/shuyaoyimei/gofeed"
 	"github.com/shuyaoyimei/gofeed/atom"
 	"github.com/shuyaoyimei/gofeed/rss"
+	"github.com/shuyaoyimei/gofeed/sitemap"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -98,3 +99,45 @@ func TestDefaultAtomTranslator_Translate_WrongType(t *testing.T) {
 	assert.Nil(t, af)
 	assert.NotNil(t, err)
 }
+
+func TestDefaultSitemapTranslator_Translate(t *testing.T) {
+	files, _ := filepath.Glob("testdata/translator/sitemap/*.xml")
+	for _, f := range files {
+		base := filepath.Base(f)
+		name := strings.TrimSuffix(base, filepath.Ext(base))
+
+		fmt.Printf("Testing %s... ", name)
+
+		// Get actual source feed
+		ff := fmt.Sprintf("testdata/translator/sitemap/%s.xml", name)
+		f, _ := os.Open(ff)
+		defer f.Close()
+
+		// Parse actual feed
+		translator := &gofeed.DefaultSitemapTranslator{}
+		fp := &sitemap.Parser{}
+		sitemapFeed, _ := fp.Parse(f)
+		actual, _ := translator.Translate(sitemapFeed)
+
+		// Get json encoded expected feed result
+		ef := fmt.Sprintf("testdata/translator/sitemap/%s.json", name)
+		e, _ := ioutil.ReadFile(ef)
+
+		// Unmarshal expected feed
+		expected := &gofeed.Feed{}
+		json.Unmarshal(e, &expected)
+
+		if assert.Equal(t, actual, expected, "Feed file %s.xml did not match expected output %s.json", name, name) {
+			fmt.Printf("OK\n")
+		} else {
+			fmt.Printf("Failed\n")
+		}
+	}
+}
+
+func TestDefaultSitemapTranslator_Translate_WrongType(t *testing.T) {
+	translator := &gofeed.DefaultSitemapTranslator{}
+	af, err := translator.Translate("wrong type")
+	assert.Nil(t, af)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,161 @@
+package gofeed
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"time"
+)
+
+const atomNS = "http://www.w3.org/2005/Atom"
+
+// WriteRSS writes the feed to w as an RSS 2.0 document, so
+// feeds of any type can be republished in a single format.
+// Dates are written in the RFC 822 format RSS uses, items
+// without a published date fall back to their updated date,
+// and items without a description carry their content in
+// it instead.  Fields RSS has no element for are dropped.
+func (f *Feed) WriteRSS(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+
+	bw.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	bw.WriteString(`<rss version="2.0"`)
+	if f.FeedLink != "" {
+		bw.WriteString(` xmlns:atom="` + atomNS + `"`)
+	}
+	bw.WriteString(">\n<channel>\n")
+
+	// title, link and description are required, even empty
+	writeRequiredElement(bw, "title", f.Title)
+	writeRequiredElement(bw, "link", f.Link)
+	writeRequiredElement(bw, "description", f.Description)
+	if f.FeedLink != "" {
+		bw.WriteString(`<atom:link rel="self" type="application/rss+xml" href="`)
+		xml.EscapeText(bw, []byte(f.FeedLink))
+		bw.WriteString(`"/>`)
+	}
+	writeElement(bw, "language", f.Language)
+	writeElement(bw, "copyright", f.Copyright)
+	writeElement(bw, "managingEditor", rssPerson(f.Author))
+	writeElement(bw, "generator", f.Generator)
+	writeElement(bw, "docs", f.Docs)
+	writeElement(bw, "pubDate", rssDate(f.PublishedParsed))
+	writeElement(bw, "lastBuildDate", rssDate(f.UpdatedParsed))
+	for _, category := range f.Categories {
+		writeElement(bw, "category", category)
+	}
+	if f.TTL > 0 {
+		writeElement(bw, "ttl", strconv.Itoa(f.TTL))
+	}
+	if f.Image != nil && f.Image.URL != "" {
+		bw.WriteString("<image>")
+		writeElement(bw, "url", f.Image.URL)
+		title := f.Image.Title
+		if title == "" {
+			title = f.Title
+		}
+		writeRequiredElement(bw, "title", title)
+		writeRequiredElement(bw, "link", f.Link)
+		bw.WriteString("</image>")
+	}
+	bw.WriteString("\n")
+
+	for _, item := range f.Items {
+		writeRSSItem(bw, item)
+	}
+
+	bw.WriteString("</channel>\n</rss>\n")
+	return bw.Flush()
+}
+
+func writeRSSItem(bw *bufio.Writer, item *Item) {
+	bw.WriteString("<item>")
+	writeElement(bw, "title", item.Title)
+	writeElement(bw, "link", item.Link)
+	description := item.Description
+	if description == "" {
+		description = item.Content
+	}
+	writeElement(bw, "description", description)
+	writeElement(bw, "author", rssPerson(item.Author))
+	for _, category := range item.Categories {
+		writeElement(bw, "category", category)
+	}
+	for _, enc := range item.Enclosures {
+		if enc.URL == "" {
+			continue
+		}
+		// An enclosure must have a length, 0 when unknown
+		length := enc.Length
+		if length == "" {
+			length = "0"
+		}
+		bw.WriteString(`<enclosure url="`)
+		xml.EscapeText(bw, []byte(enc.URL))
+		bw.WriteString(`" length="`)
+		xml.EscapeText(bw, []byte(length))
+		bw.WriteString(`" type="`)
+		xml.EscapeText(bw, []byte(enc.Type))
+		bw.WriteString(`"/>`)
+	}
+	if item.GUID != "" {
+		// Only a guid that is the item link is known to be one
+		if item.GUID == item.Link {
+			writeElement(bw, "guid", item.GUID)
+		} else {
+			bw.WriteString(`<guid isPermaLink="false">`)
+			xml.EscapeText(bw, []byte(item.GUID))
+			bw.WriteString("</guid>")
+		}
+	}
+	published := item.PublishedParsed
+	if published == nil {
+		published = item.UpdatedParsed
+	}
+	writeElement(bw, "pubDate", rssDate(published))
+	if item.Source != nil && item.Source.URL != "" {
+		bw.WriteString(`<source url="`)
+		xml.EscapeText(bw, []byte(item.Source.URL))
+		bw.WriteString(`">`)
+		xml.EscapeText(bw, []byte(item.Source.Title))
+		bw.WriteString("</source>")
+	}
+	bw.WriteString("</item>\n")
+}
+
+// writeElement writes a text element, omitting empty values
+func writeElement(bw *bufio.Writer, name, value string) {
+	if value == "" {
+		return
+	}
+	writeRequiredElement(bw, name, value)
+}
+
+func writeRequiredElement(bw *bufio.Writer, name, value string) {
+	bw.WriteString("<" + name + ">")
+	xml.EscapeText(bw, []byte(value))
+	bw.WriteString("</" + name + ">")
+}
+
+// rssDate formats t as an RFC 822 date with a four digit year
+func rssDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC1123Z)
+}
+
+// rssPerson formats p the way RSS author elements expect,
+// "email (name)", or whichever of the two is known.
+func rssPerson(p *Person) string {
+	switch {
+	case p == nil:
+		return ""
+	case p.Email != "" && p.Name != "":
+		return p.Email + " (" + p.Name + ")"
+	case p.Email != "":
+		return p.Email
+	}
+	return p.Name
+}